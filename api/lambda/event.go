@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/scraper"
+)
+
+// Event modes
+const (
+	ModeDaily     = "daily"
+	ModeRange     = "range"
+	ModeBackfill  = "backfill"
+	ModeReprocess = "reprocess" // scrape a range again, processing responses identical to the last processed ones
+)
+
+// defaultLookAheadDays is how many days ahead the daily mode scrapes
+const defaultLookAheadDays = 7
+
+// Event struct
+type Event struct {
+	Mode              string   `json:"mode,omitempty"`
+	From              string   `json:"from,omitempty"` // formatted as 2006-01-02
+	To                string   `json:"to,omitempty"`   // formatted as 2006-01-02
+	Countries         []string `json:"countries,omitempty"`
+	DryRun            bool     `json:"dryRun,omitempty"`
+	AllDays           bool     `json:"allDays,omitempty"` // also scrape weekends and exchange holidays
+	LookAheadDays     *int     `json:"lookAheadDays,omitempty"`
+	BackfillID        string   `json:"backfillId,omitempty"`
+	ContinuationToken string   `json:"continuationToken,omitempty"` // continue a partial run from its remaining tasks
+}
+
+// parseEvent decodes a lambda event, unknown fields are rejected
+func parseEvent(payload json.RawMessage) (*Event, error) {
+	event := &Event{}
+
+	// scheduled rules without input send an empty object
+	if len(bytes.TrimSpace(payload)) > 0 && !bytes.Equal(bytes.TrimSpace(payload), []byte("null")) {
+		decoder := json.NewDecoder(bytes.NewReader(payload))
+		decoder.DisallowUnknownFields()
+
+		if err := decoder.Decode(event); err != nil {
+			return nil, fmt.Errorf("invalid event: %v", err)
+		}
+	}
+
+	if event.Mode == "" {
+		event.Mode = ModeDaily
+	}
+
+	if err := event.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid event: %v", err)
+	}
+
+	return event, nil
+}
+
+// Validate checks whether fields of the event match its mode
+func (e *Event) Validate() error {
+	if e.LookAheadDays != nil && e.Mode != ModeDaily {
+		return fmt.Errorf("lookAheadDays is only allowed in %s mode", ModeDaily)
+	}
+
+	if e.BackfillID != "" && e.Mode != ModeBackfill {
+		return fmt.Errorf("backfillId is only allowed in %s mode", ModeBackfill)
+	}
+
+	switch e.Mode {
+	case ModeDaily:
+		if e.From != "" || e.To != "" {
+			return fmt.Errorf("from and to are not allowed in %s mode", e.Mode)
+		}
+
+		if e.LookAheadDays != nil && *e.LookAheadDays < 0 {
+			return fmt.Errorf("invalid lookAheadDays %d", *e.LookAheadDays)
+		}
+	case ModeRange, ModeReprocess:
+		if e.ContinuationToken != "" {
+			if e.From != "" || e.To != "" {
+				return fmt.Errorf("from and to are not allowed with a continuation token")
+			}
+			break
+		}
+
+		if e.From == "" || e.To == "" {
+			return fmt.Errorf("from and to are required in %s mode", e.Mode)
+		}
+	case ModeBackfill:
+		if e.BackfillID == "" {
+			return fmt.Errorf("backfillId is required in %s mode", e.Mode)
+		}
+
+		if e.From != "" || e.To != "" || len(e.Countries) > 0 || e.ContinuationToken != "" {
+			return fmt.Errorf("from, to, countries and continuationToken are not allowed in %s mode", e.Mode)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown mode %q, expected one of %s, %s, %s, %s", e.Mode, ModeDaily, ModeRange, ModeBackfill, ModeReprocess)
+	}
+
+	if e.ContinuationToken != "" {
+		if _, err := scraper.DecodeContinuationToken(e.ContinuationToken); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	spec, err := e.GetJobSpec()
+	if err != nil {
+		return err
+	}
+
+	return spec.Validate(time.Now())
+}
+
+// GetJobSpec gets scrape job spec of daily, range and reprocess events
+func (e *Event) GetJobSpec() (scraper.JobSpec, error) {
+	spec := scraper.JobSpec{
+		Countries: e.Countries,
+		AllDays:   e.AllDays,
+	}
+
+	if e.Mode == ModeDaily {
+		lookAheadDays := defaultLookAheadDays
+		if e.LookAheadDays != nil {
+			lookAheadDays = *e.LookAheadDays
+		}
+
+		spec.From = scraper.RelativeDate(lookAheadDays)
+		spec.To = scraper.RelativeDate(lookAheadDays)
+		return spec, nil
+	}
+
+	from, err := time.Parse("2006-01-02", e.From)
+	if err != nil {
+		return spec, fmt.Errorf("invalid from date %q", e.From)
+	}
+
+	to, err := time.Parse("2006-01-02", e.To)
+	if err != nil {
+		return spec, fmt.Errorf("invalid to date %q", e.To)
+	}
+
+	spec.From = scraper.AbsoluteDate(from)
+	spec.To = scraper.AbsoluteDate(to)
+	return spec, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		wantMode string
+		wantErr  bool
+	}{
+		{name: "empty payload is daily", payload: "", wantMode: ModeDaily},
+		{name: "scheduled rule without input", payload: "{}", wantMode: ModeDaily},
+		{name: "daily with look ahead", payload: `{"mode":"daily","lookAheadDays":3}`, wantMode: ModeDaily},
+		{name: "daily with dates", payload: `{"mode":"daily","from":"2021-03-01","to":"2021-03-05"}`, wantErr: true},
+		{name: "negative look ahead", payload: `{"lookAheadDays":-1}`, wantErr: true},
+		{name: "range", payload: `{"mode":"range","from":"2021-03-01","to":"2021-03-05","countries":["Canada"]}`, wantMode: ModeRange},
+		{name: "range without to", payload: `{"mode":"range","from":"2021-03-01"}`, wantErr: true},
+		{name: "range with invalid date", payload: `{"mode":"range","from":"2021-03-01","to":"March 5th"}`, wantErr: true},
+		{name: "range from after to", payload: `{"mode":"range","from":"2021-03-05","to":"2021-03-01"}`, wantErr: true},
+		{name: "reprocess", payload: `{"mode":"reprocess","from":"2021-03-01","to":"2021-03-05","dryRun":true}`, wantMode: ModeReprocess},
+		{name: "reprocess without dates", payload: `{"mode":"reprocess"}`, wantErr: true},
+		{name: "reprocess of unknown country", payload: `{"mode":"reprocess","from":"2021-03-01","to":"2021-03-05","countries":["Mars"]}`, wantErr: true},
+		{name: "reprocess with look ahead", payload: `{"mode":"reprocess","from":"2021-03-01","to":"2021-03-05","lookAheadDays":3}`, wantErr: true},
+		{name: "reprocess with invalid continuation token", payload: `{"mode":"reprocess","continuationToken":"not a token"}`, wantErr: true},
+		{name: "backfill", payload: `{"mode":"backfill","backfillId":"60a1b2c3d4e5f60718293a4b"}`, wantMode: ModeBackfill},
+		{name: "backfill without id", payload: `{"mode":"backfill"}`, wantErr: true},
+		{name: "backfill with dates", payload: `{"mode":"backfill","backfillId":"60a1b2c3d4e5f60718293a4b","from":"2021-03-01"}`, wantErr: true},
+		{name: "unknown mode", payload: `{"mode":"weekly"}`, wantErr: true},
+		{name: "unknown field", payload: `{"mode":"daily","days":3}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := parseEvent(json.RawMessage(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && event.Mode != tt.wantMode {
+				t.Errorf("parseEvent() mode = %s, want %s", event.Mode, tt.wantMode)
+			}
+		})
+	}
+}
@@ -2,23 +2,42 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"log"
+	"sync"
 
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambdacontext"
 	logger "github.com/lenoobz/aws-lambda-logger"
 	"github.com/lenoobz/aws-tiprank-dividend-scraper/config"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
 	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/repositories/mongodb/repos"
 	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/scraper"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/usecase/backfill"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/usecase/migration"
 	"github.com/lenoobz/aws-tiprank-dividend-scraper/usecase/tiprank"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/runid"
 )
 
+// reconcileIndexesOnce reconciles indexes on the first invocation of a container
+var reconcileIndexesOnce sync.Once
+
 func main() {
 	lambda.Start(lambdaHandler)
 }
 
-func lambdaHandler(ctx context.Context) ([]string, error) {
+func lambdaHandler(ctx context.Context, payload json.RawMessage) (*scraper.RunReport, error) {
 	log.Println("lambda handler is called")
 
+	// reject invalid events before connecting to anything
+	event, err := parseEvent(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	// the lambda request id is the run id, it ties together logs and documents of the run
+	ctx = runid.NewContext(ctx, getRunID(ctx))
+
 	appConf := config.AppConf
 
 	// create new logger
@@ -28,6 +47,10 @@ func lambdaHandler(ctx context.Context) ([]string, error) {
 	}
 	defer zap.Close()
 
+	reconcileIndexesOnce.Do(func() {
+		reconcileIndexes(ctx, zap, &appConf)
+	})
+
 	// create new repository
 	tiprankDividendRepo, err := repos.NewTipRankDividendMongo(nil, zap, &appConf.Mongo)
 	if err != nil {
@@ -38,10 +61,117 @@ func lambdaHandler(ctx context.Context) ([]string, error) {
 	// create new service
 	tiprankDividendService := tiprank.NewService(tiprankDividendRepo, zap)
 
-	// create new scraper jobs
-	jobs := scraper.NewTipRankDividendScraper(tiprankDividendService, zap)
-	jobs.StartDailyJob()
+	// create new scraper jobs, lambda has no durable directory so responses are only recorded and replayed by the cli
+	jobs, err := scraper.NewTipRankDividendScraper(tiprankDividendService, zap, &appConf.Scraper, nil)
+	if err != nil {
+		log.Fatal("create TipRank dividend scraper failed")
+	}
+	jobs.SetDryRun(event.DryRun)
+
+	// reprocessed responses are processed even when they were processed before
+	jobs.SetForce(event.Mode == ModeReprocess)
+
+	switch {
+	case event.Mode == ModeBackfill:
+		err = startBackfillJob(ctx, jobs, zap, &appConf, event)
+	case event.ContinuationToken != "":
+		err = startContinuationJob(ctx, jobs, event.ContinuationToken)
+	default:
+		err = startRangeJob(ctx, jobs, event)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	report := jobs.Close()
+	return report, nil
+}
+
+// startRangeJob scrapes dates and countries of daily, range and reprocess events
+func startRangeJob(ctx context.Context, jobs *scraper.TipRankDividendScraper, event *Event) error {
+	spec, err := event.GetJobSpec()
+	if err != nil {
+		return err
+	}
+
+	return jobs.StartRangeJob(ctx, spec)
+}
+
+// startContinuationJob scrapes remaining tasks of a partial run
+func startContinuationJob(ctx context.Context, jobs *scraper.TipRankDividendScraper, continuationToken string) error {
+	tasks, err := scraper.DecodeContinuationToken(continuationToken)
+	if err != nil {
+		return err
+	}
+
+	return jobs.StartTasksJob(ctx, tasks)
+}
+
+// startBackfillJob resumes a backfill plan, tasks left when the deadline is close stay pending for the next invocation.
+// A dry run scrapes the remaining tasks without checkpointing them
+func startBackfillJob(ctx context.Context, jobs *scraper.TipRankDividendScraper, zap logger.ContextLog, appConf *config.AppConfig, event *Event) error {
+	backfillID := event.BackfillID
+
+	// create new repository
+	backfillRepo, err := repos.NewBackfillPlanMongo(nil, zap, &appConf.Mongo)
+	if err != nil {
+		return err
+	}
+	defer backfillRepo.Close()
+
+	// create new service
+	backfillService := backfill.NewService(backfillRepo, zap)
+
+	if event.DryRun {
+		plan, err := backfillService.GetPlan(ctx, backfillID)
+		if err != nil {
+			return err
+		}
+
+		return jobs.StartTasksJob(ctx, plan.GetRemainingTasks())
+	}
+
+	tasks, err := backfillService.StartPlan(ctx, backfillID)
+	if err != nil {
+		return err
+	}
+
+	jobs.SetTaskHandler(func(ctx context.Context, task *entities.ScrapeTask, err error) {
+		backfillService.CompleteTask(ctx, backfillID, task, err)
+	})
+
+	if err := jobs.StartTasksJob(ctx, tasks); err != nil {
+		return err
+	}
+
+	_, err = backfillService.FinishPlan(ctx, backfillID)
+	return err
+}
+
+// reconcileIndexes creates missing and changed indexes, a failure is logged and the run goes on without them
+func reconcileIndexes(ctx context.Context, zap logger.ContextLog, appConf *config.AppConfig) {
+	// create new repository
+	migrationRepo, err := repos.NewMigrationMongo(nil, zap, &appConf.Mongo)
+	if err != nil {
+		zap.Error(ctx, "create migration mongo failed", "error", err)
+		return
+	}
+	defer migrationRepo.Close()
+
+	// create new service
+	migrationService := migration.NewService(migrationRepo, zap)
+
+	if _, err := migrationService.ReconcileIndexes(ctx, false); err != nil {
+		zap.Error(ctx, "reconcile indexes failed", "error", err)
+	}
+}
+
+// getRunID gets the lambda request id, or a new run id when the handler is not invoked by lambda
+func getRunID(ctx context.Context) string {
+	if lc, ok := lambdacontext.FromContext(ctx); ok && lc.AwsRequestID != "" {
+		return lc.AwsRequestID
+	}
 
-	tickers := jobs.Close()
-	return tickers, nil
+	return runid.New()
 }
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/repositories/mongodb/repos"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/scraper"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/usecase/backfill"
+)
+
+const backfillUsage = `usage: main backfill <command> [arguments]
+
+commands:
+  create --from 2019-01-01 --to 2019-06-30 [--country Canada,US] [--step 1]
+  list
+  status <id>
+  resume <id>
+  cancel <id>`
+
+// backfill runs a backfill subcommand
+func (a *app) backfill(ctx context.Context, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf(backfillUsage)
+	}
+
+	// create new repository
+	backfillRepo, err := repos.NewBackfillPlanMongo(nil, a.log, &a.conf.Mongo)
+	if err != nil {
+		return fmt.Errorf("create backfill plan mongo failed: %v", err)
+	}
+	defer backfillRepo.Close()
+
+	// create new service
+	backfillService := backfill.NewService(backfillRepo, a.log)
+
+	command, args := args[0], args[1:]
+
+	switch command {
+	case "create":
+		return a.createBackfillPlan(ctx, backfillService, args)
+	case "list":
+		return a.listBackfillPlans(ctx, backfillService)
+	case "status":
+		return a.showBackfillPlan(ctx, backfillService, args)
+	case "resume":
+		return a.resumeBackfillPlan(ctx, backfillService, args)
+	case "cancel":
+		return a.cancelBackfillPlan(ctx, backfillService, args)
+	default:
+		return fmt.Errorf("unknown backfill command %s\n%s", command, backfillUsage)
+	}
+}
+
+// createBackfillPlan creates new backfill plan from command line flags
+func (a *app) createBackfillPlan(ctx context.Context, backfillService *backfill.Service, args []string) error {
+	flags := flag.NewFlagSet("backfill create", flag.ExitOnError)
+	from := flags.String("from", "", "first date to scrape, formatted as 2006-01-02")
+	to := flags.String("to", "", "last date to scrape, formatted as 2006-01-02")
+	countries := flags.String("country", "", "comma separated TipRank countries, default to all")
+	step := flags.Int("step", 1, "number of days between two scraped dates")
+	allDays := flags.Bool("all-days", false, "also scrape weekends and exchange holidays")
+	flags.Parse(args)
+
+	fromDate, err := time.Parse("2006-01-02", *from)
+	if err != nil {
+		return fmt.Errorf("invalid --from date: %v", err)
+	}
+
+	toDate, err := time.Parse("2006-01-02", *to)
+	if err != nil {
+		return fmt.Errorf("invalid --to date: %v", err)
+	}
+
+	spec := scraper.JobSpec{
+		From:      scraper.AbsoluteDate(fromDate),
+		To:        scraper.AbsoluteDate(toDate),
+		Countries: splitList(*countries),
+		Step:      *step,
+		AllDays:   *allDays,
+	}
+
+	now := time.Now()
+	if err := spec.Validate(now); err != nil {
+		return err
+	}
+
+	plan, err := backfillService.CreatePlan(ctx, &entities.BackfillPlan{
+		From:      *from,
+		To:        *to,
+		Countries: spec.GetCountries(),
+		Step:      *step,
+	}, spec.GetTasks(now))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created backfill plan %s with %d tasks\n", plan.ID, len(plan.Tasks))
+	return nil
+}
+
+// listBackfillPlans prints all backfill plans
+func (a *app) listBackfillPlans(ctx context.Context, backfillService *backfill.Service) error {
+	plans, err := backfillService.ListPlans(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, plan := range plans {
+		counts := plan.CountTasks()
+		fmt.Printf("%s\t%s\t%s..%s\t%v\tdone=%d failed=%d pending=%d\n", plan.ID, plan.Status, plan.From, plan.To, plan.Countries,
+			counts[entities.BackfillStatusDone], counts[entities.BackfillStatusFailed], counts[entities.BackfillStatusPending])
+	}
+
+	return nil
+}
+
+// showBackfillPlan prints a backfill plan with its tasks
+func (a *app) showBackfillPlan(ctx context.Context, backfillService *backfill.Service, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: main backfill status <id>")
+	}
+
+	plan, err := backfillService.GetPlan(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	return printJSON(plan)
+}
+
+// resumeBackfillPlan scrapes remaining tasks of a backfill plan and checkpoints each of them
+func (a *app) resumeBackfillPlan(ctx context.Context, backfillService *backfill.Service, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: main backfill resume <id>")
+	}
+	id := args[0]
+
+	jobs, closeJobs, err := a.newScraper(&a.conf.Scraper)
+	if err != nil {
+		return err
+	}
+	defer closeJobs()
+
+	tasks, err := backfillService.StartPlan(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	jobs.SetTaskHandler(func(ctx context.Context, task *entities.ScrapeTask, err error) {
+		backfillService.CompleteTask(ctx, id, task, err)
+	})
+
+	if err := jobs.StartTasksJob(ctx, tasks); err != nil {
+		return err
+	}
+	jobs.Close()
+
+	plan, err := backfillService.FinishPlan(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	counts := plan.CountTasks()
+	fmt.Printf("backfill plan %s is %s: done=%d failed=%d pending=%d\n", plan.ID, plan.Status,
+		counts[entities.BackfillStatusDone], counts[entities.BackfillStatusFailed], counts[entities.BackfillStatusPending])
+	return nil
+}
+
+// cancelBackfillPlan cancels a backfill plan
+func (a *app) cancelBackfillPlan(ctx context.Context, backfillService *backfill.Service, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: main backfill cancel <id>")
+	}
+
+	if err := backfillService.CancelPlan(ctx, args[0]); err != nil {
+		return err
+	}
+
+	fmt.Printf("cancelled backfill plan %s\n", args[0])
+	return nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/config"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/consts"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/repositories/mongodb/repos"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/scraper"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/usecase/migration"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/calendar"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/symbology"
+)
+
+// doctorCheck is a named check of the doctor command
+type doctorCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// doctor checks config, database and TipRank connectivity
+func (a *app) doctor(ctx context.Context, args []string) error {
+	checks := []doctorCheck{
+		{"mongo config", a.checkMongoConfig},
+		{"mongo connection", a.checkMongoConnection},
+		{"schema migrations", a.checkMigrations},
+		{"scraper config", a.checkScraperConfig},
+		{"market config", a.checkMarketConfig},
+		{"TipRank connection", a.checkTipRankConnection},
+	}
+
+	fmt.Printf("environment: %s\n", a.env)
+
+	failed := 0
+	for _, c := range checks {
+		if err := c.check(ctx); err != nil {
+			failed++
+			fmt.Printf("FAIL  %s: %v\n", c.name, err)
+			continue
+		}
+
+		fmt.Printf("OK    %s\n", c.name)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+
+	return nil
+}
+
+// checkMongoConfig checks connection settings and collection names
+func (a *app) checkMongoConfig(ctx context.Context) error {
+	conf := a.conf.Mongo
+
+	if conf.URI == "" && (conf.Host == "" || conf.Username == "" || conf.Password == "") {
+		return fmt.Errorf("missing mongo host, username or password, set MONGO_DB_* variables or --mongo-uri")
+	}
+
+	if conf.Dbname == "" {
+		return fmt.Errorf("missing database name")
+	}
+
+	for _, colname := range []string{consts.TIPRANK_DIVIDEND_LIST_COLLECTION, consts.TIPRANK_BACKFILL_PLAN_COLLECTION, consts.TIPRANK_RESPONSE_QUARANTINE_COLLECTION, consts.TIPRANK_RESPONSE_HASH_COLLECTION, consts.TIPRANK_DIVIDEND_REJECT_COLLECTION, consts.TIPRANK_MIGRATION_COLLECTION, consts.TIPRANK_DIVIDEND_EVENT_COLLECTION} {
+		if _, ok := conf.Colnames[colname]; !ok {
+			return fmt.Errorf("missing collection name of %s", colname)
+		}
+	}
+
+	return nil
+}
+
+// checkMongoConnection checks the database can be reached
+func (a *app) checkMongoConnection(ctx context.Context) error {
+	tiprankDividendRepo, err := repos.NewTipRankDividendMongo(nil, a.log, &a.conf.Mongo)
+	if err != nil {
+		return err
+	}
+	defer tiprankDividendRepo.Close()
+
+	return tiprankDividendRepo.Ping(ctx)
+}
+
+// checkMigrations checks every migration up to the configured schema version has been applied
+func (a *app) checkMigrations(ctx context.Context) error {
+	migrationRepo, err := repos.NewMigrationMongo(nil, a.log, &a.conf.Mongo)
+	if err != nil {
+		return err
+	}
+	defer migrationRepo.Close()
+
+	report, err := migration.NewService(migrationRepo, a.log).Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	if pending := report.GetPendingMigrations(); len(pending) > 0 {
+		return fmt.Errorf("%d migrations up to schema version %d are pending, run `main migrate up`", len(pending), report.SchemaVersion)
+	}
+
+	return nil
+}
+
+// checkScraperConfig checks TipRank url, proxies and fixture directories
+func (a *app) checkScraperConfig(ctx context.Context) error {
+	conf := a.conf.Scraper
+
+	if _, err := conf.GetAllowedDomains(); err != nil {
+		return err
+	}
+
+	if len(conf.Proxies) > 0 {
+		if _, err := scraper.NewProxyPool(conf.Proxies, scraper.ProxyStrategy(conf.ProxyStrategy), time.Duration(conf.ProxyCooldownMS)*time.Millisecond, conf.ProxyMaxFailures); err != nil {
+			return err
+		}
+	}
+
+	if conf.RecordDir != "" {
+		if err := os.MkdirAll(conf.RecordDir, 0755); err != nil {
+			return fmt.Errorf("record directory is not writable: %v", err)
+		}
+	}
+
+	if conf.ReplayDir != "" {
+		if _, err := os.Stat(conf.ReplayDir); err != nil {
+			return fmt.Errorf("replay directory is not readable: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// checkMarketConfig checks every market of the registry, that its exchanges and calendar are known and that at least one is enabled
+func (a *app) checkMarketConfig(ctx context.Context) error {
+	for i := range config.Markets {
+		market := &config.Markets[i]
+		if err := market.Validate(); err != nil {
+			return err
+		}
+
+		if !symbology.IsKnownExchange(market.DefaultExchange) {
+			return fmt.Errorf("market %s has unknown default exchange %s", market.Code, market.DefaultExchange)
+		}
+
+		for prefix, exchange := range market.TickerPrefixes {
+			if !symbology.IsKnownExchange(exchange) {
+				return fmt.Errorf("market %s has unknown exchange %s for prefix %s", market.Code, exchange, prefix)
+			}
+		}
+
+		marketCalendar, err := calendar.GetCalendar(market.Calendar)
+		if err != nil {
+			return fmt.Errorf("market %s has invalid calendar: %v", market.Code, err)
+		}
+
+		if !marketCalendar.Covers(time.Now()) {
+			return fmt.Errorf("calendar %s of market %s has no holidays of this year, update the holiday data", marketCalendar.Name, market.Code)
+		}
+	}
+
+	if len(config.GetEnabledMarketCodes()) == 0 {
+		return fmt.Errorf("no market is enabled")
+	}
+
+	return nil
+}
+
+// checkTipRankConnection requests today's US dividends from TipRank
+func (a *app) checkTipRankConnection(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	url := a.conf.Scraper.GetDividendStockByDateURL("US", time.Now())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded %s", url, res.Status)
+	}
+
+	if _, err := ioutil.ReadAll(res.Body); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/symbology"
+)
+
+// export writes stored dividends as json or csv
+func (a *app) export(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "json", "output format: json or csv, csv has one row per dividend")
+	out := flags.String("out", "", "output file, default to stdout")
+	flags.Parse(args)
+
+	if *format != "json" && *format != "csv" {
+		return fmt.Errorf("unknown format %s", *format)
+	}
+
+	tiprankDividendService, closeService, err := a.newTipRankDividendService()
+	if err != nil {
+		return err
+	}
+	defer closeService()
+
+	var dividendStocks []*entities.DividendStock
+	for cursor := ""; ; {
+		page, err := tiprankDividendService.ListAll(ctx, cursor, 0)
+		if err != nil {
+			return err
+		}
+
+		dividendStocks = append(dividendStocks, page.DividendStocks...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *format == "csv" {
+		return exportCSV(w, dividendStocks)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(dividendStocks)
+}
+
+// exportCSV writes one row per dividend of each stock, oldest first
+func exportCSV(w io.Writer, dividendStocks []*entities.DividendStock) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{"ticker", "exchange", "symbol", "yahooTicker", "bloombergTicker", "name", "currency", "dividend", "exDividendDate", "recordDate", "payoutDate"}); err != nil {
+		return err
+	}
+
+	for _, dividendStock := range dividendStocks {
+		var keys []int64
+		for k := range dividendStock.DividendHistory {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+		// legacy documents have no exchange yet
+		var yahooTicker, bloombergTicker string
+		if dividendStock.Exchange != "" {
+			symbol := &entities.Symbol{
+				Exchange: dividendStock.Exchange,
+				Symbol:   dividendStock.Symbol,
+			}
+			yahooTicker, _ = symbology.ToYahoo(symbol)
+			bloombergTicker, _ = symbology.ToBloomberg(symbol)
+		}
+
+		for _, k := range keys {
+			dividendHistory := dividendStock.DividendHistory[k]
+
+			record := []string{
+				dividendStock.Ticker,
+				dividendStock.Exchange,
+				dividendStock.Symbol,
+				yahooTicker,
+				bloombergTicker,
+				dividendStock.Name,
+				dividendStock.Currency,
+				strconv.FormatFloat(dividendHistory.Dividend, 'f', -1, 64),
+				formatDate(dividendHistory.ExDividendDate),
+				formatDate(dividendHistory.RecordDate),
+				formatDate(dividendHistory.DividendDate),
+			}
+
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatDate formats an optional date as 2006-01-02
+func formatDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+
+	return date.Format("2006-01-02")
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/config"
+)
+
+// history scrapes TipRank dividend history of given tickers and prints the run report
+func (a *app) history(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("history", flag.ExitOnError)
+	country := flags.String("country", "US", "TipRank country of the tickers")
+	dryRun := flags.Bool("dry-run", false, "parse responses without saving dividends")
+	recordDir := flags.String("record", "", "write raw responses to this directory")
+	replayDir := flags.String("replay", "", "read responses from this directory instead of TipRank")
+	baseURL := flags.String("base-url", "", "TipRank base url, e.g. a local fake server")
+	proxies := flags.String("proxy", "", "comma separated http, https or socks5 proxy urls, overrides SCRAPER_PROXIES")
+	proxyStrategy := flags.String("proxy-strategy", "", "proxy rotation: round_robin, random or sticky per country")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: main history [--country COUNTRY] [--dry-run] [--record DIR | --replay DIR] TICKER...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	tickers := flags.Args()
+	if len(tickers) == 0 {
+		flags.Usage()
+		return fmt.Errorf("missing tickers")
+	}
+
+	scraperConf := a.conf.Scraper
+	scraperConf.RecordDir = *recordDir
+	scraperConf.ReplayDir = *replayDir
+	if *proxies != "" {
+		scraperConf.Proxies = config.GetProxyList(*proxies)
+	}
+	if *proxyStrategy != "" {
+		scraperConf.ProxyStrategy = *proxyStrategy
+	}
+	if *baseURL != "" {
+		scraperConf.BaseURL = *baseURL
+		scraperConf.AllowedDomains = nil
+		scraperConf.DomainGlob = ""
+	}
+
+	jobs, closeJobs, err := a.newScraper(&scraperConf)
+	if err != nil {
+		return err
+	}
+	defer closeJobs()
+
+	jobs.SetDryRun(*dryRun)
+
+	if err := jobs.StartHistoryJob(ctx, tickers, *country); err != nil {
+		return err
+	}
+
+	return printJSON(jobs.Close())
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+)
+
+// list prints stored dividends of tickers, of an ex-dividend or payout date range, upcoming dividends, or a page of all stocks
+func (a *app) list(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	tickers := flags.String("tickers", "", "comma separated tickers")
+	exFrom := flags.String("ex-from", "", "first ex-dividend date: 2006-01-02, today, or days from today such as -7")
+	exTo := flags.String("ex-to", "today", "last ex-dividend date, with --ex-from")
+	payFrom := flags.String("pay-from", "", "first payout date: 2006-01-02, today, or days from today such as -7")
+	payTo := flags.String("pay-to", "today", "last payout date, with --pay-from")
+	upcoming := flags.Int("upcoming", -1, "dividends going ex-dividend in this number of days")
+	country := flags.String("country", "", "TipRank country of date range and upcoming dividends, default to all")
+	cursor := flags.String("cursor", "", "next cursor of the previous page of all stocks")
+	limit := flags.Int("limit", 100, "page size of all stocks")
+	flags.Parse(args)
+
+	tiprankDividendService, closeService, err := a.newTipRankDividendService()
+	if err != nil {
+		return err
+	}
+	defer closeService()
+
+	var dividendStocks []*entities.DividendStock
+	switch {
+	case *tickers != "":
+		dividendStocks, err = tiprankDividendService.GetByTickers(ctx, splitList(*tickers))
+	case *exFrom != "":
+		var from, to time.Time
+		if from, to, err = parseDateRange(*exFrom, *exTo); err != nil {
+			return err
+		}
+		dividendStocks, err = tiprankDividendService.ListByExDateRange(ctx, from, to, *country)
+	case *payFrom != "":
+		var from, to time.Time
+		if from, to, err = parseDateRange(*payFrom, *payTo); err != nil {
+			return err
+		}
+		dividendStocks, err = tiprankDividendService.ListByPayDateRange(ctx, from, to, *country)
+	case *upcoming >= 0:
+		dividendStocks, err = tiprankDividendService.ListUpcoming(ctx, *upcoming, *country)
+	default:
+		page, err := tiprankDividendService.ListAll(ctx, *cursor, *limit)
+		if err != nil {
+			return err
+		}
+		return printJSON(page)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return printJSON(dividendStocks)
+}
+
+// parseDateRange parses first and last dates of a range, dates are at midnight UTC as stored dividend dates
+func parseDateRange(from string, to string) (time.Time, time.Time, error) {
+	fromDate, err := parseJobDate(from)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid first date: %v", err)
+	}
+
+	toDate, err := parseJobDate(to)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid last date: %v", err)
+	}
+
+	now := time.Now()
+	return toUTCDate(fromDate.Resolve(now)), toUTCDate(toDate.Resolve(now)), nil
+}
+
+// toUTCDate gets midnight UTC of the day of a time
+func toUTCDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -1,39 +1,117 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	logger "github.com/lenoobz/aws-lambda-logger"
 	"github.com/lenoobz/aws-tiprank-dividend-scraper/config"
-	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/repositories/mongodb/repos"
-	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/scraper"
-	"github.com/lenoobz/aws-tiprank-dividend-scraper/usecase/tiprank"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/runid"
 )
 
+const usage = `usage: main [--env ENV] [--mongo-uri URI] <command> [arguments]
+
+the environment defaults to the one the binary is built for, e.g. make build-cmd LIBRARY_ENV=prod
+
+commands:
+  scrape    scrape TipRank dividends of a date range
+  history   scrape TipRank dividend history of tickers
+  source    pull dividends of a date range from TipRank, csv files or other sources
+  backfill  create, list, inspect, resume and cancel backfill plans
+  show      print stored dividends of a ticker
+  list      list stored dividends of tickers, date ranges, upcoming dividends or pages of all stocks
+  export    export stored dividends as json or csv
+  migrate   print migration status, reconcile indexes and run pending migrations, or dry-run them
+  doctor    check config, database and TipRank connectivity
+
+run "main <command> --help" for the arguments of a command`
+
+// app holds what every command needs
+type app struct {
+	env  string
+	conf *config.AppConfig
+	log  logger.ContextLog
+}
+
 func main() {
-	appConf := config.AppConf
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	env := flag.String("env", config.Env, "environment whose config is loaded, one of "+strings.Join(config.GetEnvs(), ", "))
+	mongoURI := flag.String("mongo-uri", "", "mongo connection string, overrides the configured host and credentials")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		return fmt.Errorf("missing command")
+	}
+
+	appConf, err := config.GetAppConf(*env)
+	if err != nil {
+		return err
+	}
+
+	if *mongoURI != "" {
+		appConf.Mongo.URI = *mongoURI
+	}
 
 	// create new logger
 	zap, err := logger.NewZapLogger()
 	if err != nil {
-		log.Fatal("create app logger failed")
+		return fmt.Errorf("create app logger failed: %v", err)
 	}
 	defer zap.Close()
 
-	// create new repository
-	tiprankDividendRepo, err := repos.NewTipRankDividendMongo(nil, zap, &appConf.Mongo)
-	if err != nil {
-		log.Fatal("create TipRank dividend mongo failed")
+	a := &app{
+		env:  *env,
+		conf: &appConf,
+		log:  zap,
 	}
-	defer tiprankDividendRepo.Close()
 
-	// create new service
-	tiprankDividendService := tiprank.NewService(tiprankDividendRepo, zap)
+	// every command is a run with its own run id
+	ctx := runid.NewContext(context.Background(), runid.New())
+	command, args := flag.Arg(0), flag.Args()[1:]
+
+	switch command {
+	case "scrape":
+		return a.scrape(ctx, args)
+	case "history":
+		return a.history(ctx, args)
+	case "source":
+		return a.source(ctx, args)
+	case "backfill":
+		return a.backfill(ctx, args)
+	case "show":
+		return a.show(ctx, args)
+	case "list":
+		return a.list(ctx, args)
+	case "export":
+		return a.export(ctx, args)
+	case "migrate":
+		return a.migrate(ctx, args)
+	case "doctor":
+		return a.doctor(ctx, args)
+	default:
+		flag.Usage()
+		return fmt.Errorf("unknown command %s", command)
+	}
+}
 
-	// create new scraper jobs
-	jobs := scraper.NewTipRankDividendScraper(tiprankDividendService, zap)
-	// jobs.StartSingleDayJob()
-	// jobs.StartPreviousWeekJob()
-	jobs.StartNextWeekJob()
-	jobs.StartPreviousYearJob()
+// printJSON prints a value as indented json
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
 }
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/repositories/mongodb/repos"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/usecase/migration"
+)
+
+const migrateUsage = `usage: main migrate <command>
+
+commands:
+  status   print migrations up to the configured schema version and whether they have been applied
+  up       reconcile indexes and run pending migrations
+  dry-run  print what up would change without changing it`
+
+// migrate runs a migrate subcommand
+func (a *app) migrate(ctx context.Context, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf(migrateUsage)
+	}
+
+	// create new repository
+	migrationRepo, err := repos.NewMigrationMongo(nil, a.log, &a.conf.Mongo)
+	if err != nil {
+		return fmt.Errorf("create migration mongo failed: %v", err)
+	}
+	defer migrationRepo.Close()
+
+	// create new service
+	migrationService := migration.NewService(migrationRepo, a.log)
+
+	command := args[0]
+
+	switch command {
+	case "status":
+		report, err := migrationService.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		return printJSON(report)
+	case "up", "dry-run":
+		report, err := migrationService.Up(ctx, command == "dry-run")
+		if report != nil {
+			if printErr := printJSON(report); printErr != nil {
+				return printErr
+			}
+		}
+
+		return err
+	default:
+		return fmt.Errorf("unknown migrate command %s\n%s", command, migrateUsage)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/config"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/repositories/mongodb/repos"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/scraper"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/usecase/tiprank"
+)
+
+// scrape scrapes TipRank dividends of a date range and prints the run report
+func (a *app) scrape(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("scrape", flag.ExitOnError)
+	from := flags.String("from", "today", "first date to scrape: 2006-01-02, today, or days from today such as -7")
+	to := flags.String("to", "today", "last date to scrape: 2006-01-02, today, or days from today such as +7")
+	countries := flags.String("country", "", "comma separated TipRank countries, default to all")
+	step := flags.Int("step", 1, "number of days between two scraped dates")
+	allDays := flags.Bool("all-days", false, "also scrape weekends and exchange holidays")
+	dryRun := flags.Bool("dry-run", false, "parse responses without saving dividends")
+	force := flags.Bool("force", false, "process responses even when identical to the last processed ones")
+	recordDir := flags.String("record", "", "write raw responses to this directory")
+	replayDir := flags.String("replay", "", "read responses from this directory instead of TipRank")
+	baseURL := flags.String("base-url", "", "TipRank base url, e.g. a local fake server")
+	proxies := flags.String("proxy", "", "comma separated http, https or socks5 proxy urls, overrides SCRAPER_PROXIES")
+	proxyStrategy := flags.String("proxy-strategy", "", "proxy rotation: round_robin, random or sticky per country")
+	flags.Parse(args)
+
+	fromDate, err := parseJobDate(*from)
+	if err != nil {
+		return fmt.Errorf("invalid --from: %v", err)
+	}
+
+	toDate, err := parseJobDate(*to)
+	if err != nil {
+		return fmt.Errorf("invalid --to: %v", err)
+	}
+
+	spec := scraper.JobSpec{
+		From:      fromDate,
+		To:        toDate,
+		Countries: splitList(*countries),
+		Step:      *step,
+		AllDays:   *allDays,
+	}
+
+	scraperConf := a.conf.Scraper
+	scraperConf.RecordDir = *recordDir
+	scraperConf.ReplayDir = *replayDir
+	if *proxies != "" {
+		scraperConf.Proxies = config.GetProxyList(*proxies)
+	}
+	if *proxyStrategy != "" {
+		scraperConf.ProxyStrategy = *proxyStrategy
+	}
+	if *baseURL != "" {
+		scraperConf.BaseURL = *baseURL
+		scraperConf.AllowedDomains = nil
+		scraperConf.DomainGlob = ""
+	}
+
+	jobs, closeJobs, err := a.newScraper(&scraperConf)
+	if err != nil {
+		return err
+	}
+	defer closeJobs()
+
+	jobs.SetDryRun(*dryRun)
+	jobs.SetForce(*force || *replayDir != "")
+
+	if err := jobs.StartRangeJob(ctx, spec); err != nil {
+		return err
+	}
+
+	return printJSON(jobs.Close())
+}
+
+// newScraper creates TipRank dividend scraper and a function closing its repository
+func (a *app) newScraper(scraperConf *config.ScraperConfig) (*scraper.TipRankDividendScraper, func(), error) {
+	// create new repository
+	tiprankDividendRepo, err := repos.NewTipRankDividendMongo(nil, a.log, &a.conf.Mongo)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create TipRank dividend mongo failed: %v", err)
+	}
+
+	// create new service
+	tiprankDividendService := tiprank.NewService(tiprankDividendRepo, a.log)
+
+	// create new scraper jobs
+	jobs, err := scraper.NewTipRankDividendScraper(tiprankDividendService, a.log, scraperConf, nil)
+	if err != nil {
+		tiprankDividendRepo.Close()
+		return nil, nil, fmt.Errorf("create TipRank dividend scraper failed: %v", err)
+	}
+
+	return jobs, tiprankDividendRepo.Close, nil
+}
+
+// parseJobDate parses an absolute date (2006-01-02), today, or a number of days relative to today (+7, -365)
+func parseJobDate(value string) (scraper.JobDate, error) {
+	if value == "today" {
+		return scraper.RelativeDate(0), nil
+	}
+
+	if strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-") {
+		days, err := strconv.Atoi(value)
+		if err != nil {
+			return scraper.JobDate{}, err
+		}
+
+		return scraper.RelativeDate(days), nil
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return scraper.JobDate{}, err
+	}
+
+	return scraper.AbsoluteDate(date), nil
+}
+
+// splitList splits a comma separated list, an empty string gives an empty list
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/repositories/mongodb/repos"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/usecase/tiprank"
+)
+
+// show prints stored dividends of a ticker
+func (a *app) show(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("show", flag.ExitOnError)
+	country := flags.String("country", "", "TipRank country of the ticker, looks the ticker up by its exchange and symbol")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: main show [--country COUNTRY] <ticker>")
+	}
+	ticker := flags.Arg(0)
+
+	tiprankDividendService, closeService, err := a.newTipRankDividendService()
+	if err != nil {
+		return err
+	}
+	defer closeService()
+
+	var dividendStock *entities.DividendStock
+	if *country != "" {
+		dividendStock, err = tiprankDividendService.GetBySymbol(ctx, ticker, *country)
+	} else {
+		dividendStock, err = tiprankDividendService.GetByTicker(ctx, ticker)
+	}
+	if err != nil {
+		return err
+	}
+
+	if dividendStock == nil {
+		return fmt.Errorf("ticker %s not found", ticker)
+	}
+
+	return printJSON(dividendStock)
+}
+
+// newTipRankDividendService creates TipRank dividend service and a function closing its repository
+func (a *app) newTipRankDividendService() (*tiprank.Service, func(), error) {
+	// create new repository
+	tiprankDividendRepo, err := repos.NewTipRankDividendMongo(nil, a.log, &a.conf.Mongo)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create TipRank dividend mongo failed: %v", err)
+	}
+
+	// create new service
+	tiprankDividendService := tiprank.NewService(tiprankDividendRepo, a.log)
+
+	return tiprankDividendService, tiprankDividendRepo.Close, nil
+}
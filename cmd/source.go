@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/config"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/repositories/mongodb/repos"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/sources"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/usecase/source"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/usecase/tiprank"
+)
+
+// source pulls dividend events of a date range from dividend sources and prints the result of each source
+func (a *app) source(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("source", flag.ExitOnError)
+	from := flags.String("from", "today", "first ex-dividend date: 2006-01-02, today, or days from today such as -7")
+	to := flags.String("to", "today", "last ex-dividend date: 2006-01-02, today, or days from today such as +7")
+	countries := flags.String("country", "", "comma separated TipRank countries, default to all enabled markets")
+	sourceNames := flags.String("source", "", "comma separated sources: tiprank, csv, default to all registered sources")
+	csvPath := flags.String("csv", "", "register csv source reading this file or directory of csv files")
+	flags.Parse(args)
+
+	fromDate, err := parseJobDate(*from)
+	if err != nil {
+		return fmt.Errorf("invalid --from: %v", err)
+	}
+
+	toDate, err := parseJobDate(*to)
+	if err != nil {
+		return fmt.Errorf("invalid --to: %v", err)
+	}
+
+	countryCodes := splitList(*countries)
+	if len(countryCodes) == 0 {
+		countryCodes = config.GetEnabledMarketCodes()
+	}
+
+	// create new repository
+	tiprankDividendRepo, err := repos.NewTipRankDividendMongo(nil, a.log, &a.conf.Mongo)
+	if err != nil {
+		return fmt.Errorf("create TipRank dividend mongo failed: %v", err)
+	}
+	defer tiprankDividendRepo.Close()
+
+	// create new services
+	tiprankDividendService := tiprank.NewService(tiprankDividendRepo, a.log)
+
+	jobs, closeJobs, err := a.newScraper(&a.conf.Scraper)
+	if err != nil {
+		return err
+	}
+	defer closeJobs()
+
+	registry := source.NewRegistry()
+	if err := registry.Register(sources.NewTipRankSource(jobs)); err != nil {
+		return err
+	}
+
+	if *csvPath != "" {
+		if err := registry.Register(sources.NewCSVSource(sources.CSVSourceName, *csvPath)); err != nil {
+			return err
+		}
+	}
+
+	sourceService := source.NewService(registry, tiprankDividendService, a.log)
+
+	now := time.Now()
+	results, err := sourceService.Run(ctx, splitList(*sourceNames), countryCodes, fromDate.Resolve(now), toDate.Resolve(now))
+	if err != nil {
+		return err
+	}
+
+	return printJSON(results)
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// appConfs are configs of every environment keyed by environment name
+var appConfs = map[string]AppConfig{
+	"dev":     devAppConf,
+	"local":   localAppConf,
+	"staging": stagingAppConf,
+	"prod":    prodAppConf,
+	"testing": testingAppConf,
+}
+
+// AppConf is config of the environment the binary is built for
+var AppConf = appConfs[Env]
+
+// GetAppConf gets config of a given environment
+func GetAppConf(env string) (AppConfig, error) {
+	appConf, found := appConfs[env]
+	if !found {
+		return AppConfig{}, fmt.Errorf("unknown environment %s, expected one of %s", env, strings.Join(GetEnvs(), ", "))
+	}
+
+	return appConf, nil
+}
+
+// GetEnvs gets names of all environments
+func GetEnvs() []string {
+	var envs []string
+	for env := range appConfs {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+
+	return envs
+}
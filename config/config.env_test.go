@@ -0,0 +1,44 @@
+package config
+
+import "testing"
+
+func TestGetAppConf(t *testing.T) {
+	tests := []struct {
+		env     string
+		wantErr bool
+	}{
+		{env: "dev"},
+		{env: "local"},
+		{env: "staging"},
+		{env: "prod"},
+		{env: "testing"},
+		{env: "qa", wantErr: true},
+		{env: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			appConf, err := GetAppConf(tt.env)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetAppConf(%q) error = %v, wantErr %v", tt.env, err, tt.wantErr)
+			}
+
+			if err == nil && appConf.Mongo.SchemaVersion == "" {
+				t.Errorf("GetAppConf(%q) has no schema version", tt.env)
+			}
+		})
+	}
+}
+
+func TestGetAppConfIsACopy(t *testing.T) {
+	appConf, err := GetAppConf(Env)
+	if err != nil {
+		t.Fatalf("GetAppConf(%q) error = %v", Env, err)
+	}
+
+	appConf.Mongo.URI = "mongodb://localhost:27017"
+
+	if saved, _ := GetAppConf(Env); saved.Mongo.URI != "" {
+		t.Errorf("GetAppConf(%q) kept a changed uri %s", Env, saved.Mongo.URI)
+	}
+}
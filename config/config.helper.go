@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 )
 
@@ -11,7 +13,69 @@ const AllowDomain = "www.tipranks.com"
 // DomainGlob const
 const DomainGlob = "*tipranks.*"
 
-// GetFundOverviewURL get fund overview url
-func GetDividendStockByDateURL(countryCode string, date time.Time) string {
-	return fmt.Sprintf("https://www.tipranks.com/api/dividends/getByDate/?name=%s&country=%s", date.Format("2006-01-02"), countryCode)
+// BaseURL const
+const BaseURL = "https://www.tipranks.com"
+
+// DividendPathTemplate const, {date} and {country} placeholders are replaced on each request
+const DividendPathTemplate = "/api/dividends/getByDate/?name={date}&country={country}"
+
+// HistoryPathTemplate const, {ticker} placeholder is replaced on each request
+const HistoryPathTemplate = "/api/stocks/getDividends/?name={ticker}"
+
+// GetDividendStockByDateURL get dividend stocks by date url
+func (c *ScraperConfig) GetDividendStockByDateURL(countryCode string, date time.Time) string {
+	path := strings.NewReplacer(
+		"{date}", url.QueryEscape(date.Format("2006-01-02")),
+		"{country}", url.QueryEscape(countryCode),
+	).Replace(c.DividendPathTemplate)
+
+	return strings.TrimRight(c.BaseURL, "/") + path
+}
+
+// GetDividendHistoryByTickerURL get dividend history by ticker url
+func (c *ScraperConfig) GetDividendHistoryByTickerURL(ticker string) string {
+	path := strings.NewReplacer(
+		"{ticker}", url.QueryEscape(ticker),
+	).Replace(c.HistoryPathTemplate)
+
+	return strings.TrimRight(c.BaseURL, "/") + path
+}
+
+// GetProxyList splits a comma separated list of proxy urls, e.g. of the SCRAPER_PROXIES variable
+func GetProxyList(value string) []string {
+	var proxies []string
+	for _, proxy := range strings.Split(value, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+
+	return proxies
+}
+
+// GetAllowedDomains get domains the scraper is allowed to visit, default to the host of the base url
+func (c *ScraperConfig) GetAllowedDomains() ([]string, error) {
+	if len(c.AllowedDomains) > 0 {
+		return c.AllowedDomains, nil
+	}
+
+	baseURL, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if baseURL.Hostname() == "" {
+		return nil, fmt.Errorf("invalid base url %s", c.BaseURL)
+	}
+
+	return []string{baseURL.Hostname()}, nil
+}
+
+// GetDomainGlob get domain glob of the scraper limit rule, default to all domains
+func (c *ScraperConfig) GetDomainGlob() string {
+	if c.DomainGlob == "" {
+		return "*"
+	}
+
+	return c.DomainGlob
 }
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	// embed timezone database, lambda runtimes do not always ship one
+	_ "time/tzdata"
+)
+
+// Markets is the registry of TipRank markets, set Enabled to start or stop scraping a market
+var Markets = []MarketConfig{
+	{
+		Code:            "US",
+		ISOCountry:      "US",
+		Currency:        "USD",
+		Timezone:        "America/New_York",
+		Calendar:        "NYSE",
+		DefaultExchange: "US",
+		TickerPattern:   `^[A-Z][A-Z0-9.\-]*$`,
+		Enabled:         true,
+	},
+	{
+		Code:            "Canada",
+		ISOCountry:      "CA",
+		Currency:        "CAD",
+		Timezone:        "America/Toronto",
+		Calendar:        "TSX",
+		DefaultExchange: "TSX",
+		TickerPrefixes:  map[string]string{"TSE:": "TSX", "CVE:": "TSXV"},
+		TickerPattern:   `^((TSE|CVE):)?[A-Z][A-Z0-9.\-]*$`,
+		Enabled:         true,
+	},
+	{
+		Code:            "UK",
+		ISOCountry:      "GB",
+		Currency:        "GBP",
+		Timezone:        "Europe/London",
+		Calendar:        "LSE",
+		DefaultExchange: "LSE",
+		TickerPrefixes:  map[string]string{"GB:": "LSE", "LON:": "LSE"},
+		TickerPattern:   `^((GB|LON):)?[A-Z0-9][A-Z0-9.\-]*$`,
+		Enabled:         true,
+	},
+}
+
+// GetMarket gets market of a given TipRank country code
+func GetMarket(code string) (*MarketConfig, error) {
+	for i := range Markets {
+		if Markets[i].Code == code {
+			return &Markets[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown market %s", code)
+}
+
+// GetEnabledMarketCodes gets TipRank country codes of all enabled markets
+func GetEnabledMarketCodes() []string {
+	var codes []string
+	for _, market := range Markets {
+		if market.Enabled {
+			codes = append(codes, market.Code)
+		}
+	}
+
+	return codes
+}
+
+// GetLocation gets timezone of the market exchange
+func (m *MarketConfig) GetLocation() (*time.Location, error) {
+	return time.LoadLocation(m.Timezone)
+}
+
+// GetExchanges gets exchanges of the market, its default exchange first
+func (m *MarketConfig) GetExchanges() []string {
+	exchanges := []string{m.DefaultExchange}
+
+	var prefixExchanges []string
+	for _, exchange := range m.TickerPrefixes {
+		prefixExchanges = append(prefixExchanges, exchange)
+	}
+	sort.Strings(prefixExchanges)
+
+	for _, exchange := range prefixExchanges {
+		if exchange != exchanges[len(exchanges)-1] && exchange != m.DefaultExchange {
+			exchanges = append(exchanges, exchange)
+		}
+	}
+
+	return exchanges
+}
+
+// IsValidTicker checks whether a TipRank ticker matches the ticker format of the market
+func (m *MarketConfig) IsValidTicker(ticker string) (bool, error) {
+	if m.TickerPattern == "" {
+		return true, nil
+	}
+
+	return regexp.MatchString(m.TickerPattern, ticker)
+}
+
+// Validate checks whether the market config is usable
+func (m *MarketConfig) Validate() error {
+	if m.Code == "" || m.Currency == "" || m.DefaultExchange == "" {
+		return fmt.Errorf("market %q misses code, currency or default exchange", m.Code)
+	}
+
+	if _, err := m.GetLocation(); err != nil {
+		return fmt.Errorf("market %s has invalid timezone: %v", m.Code, err)
+	}
+
+	if _, err := regexp.Compile(m.TickerPattern); err != nil {
+		return fmt.Errorf("market %s has invalid ticker pattern: %v", m.Code, err)
+	}
+
+	return nil
+}
@@ -12,9 +12,51 @@ type MongoConfig struct {
 	Host          string
 	Dbname        string
 	Colnames      map[string]string
+	URI           string // overrides the connection string built from host, username and password when set
+}
+
+// ScraperConfig struct
+type ScraperConfig struct {
+	BaseURL              string
+	DividendPathTemplate string
+	HistoryPathTemplate  string
+	AllowedDomains       []string
+	DomainGlob           string
+	RecordDir            string // write every raw response to this directory when set
+	ReplayDir            string // read responses from this directory instead of TipRank when set
+	MaxRetries           int
+	RetryBaseDelayMS     uint64
+	RetryMaxDelayMS      uint64
+	DeadlineMarginMS     uint64 // stop sending requests when the run deadline is closer than this
+	Parallelism          int
+	RandomDelayMS        uint64
+	RequestsPerSecond    float64 // initial rate of the adaptive rate limiter, zero disables pacing
+	MinRequestsPerSecond float64
+	MaxRequestsPerSecond float64
+	Burst                int
+	LatencyThresholdMS   uint64   // responses slower than this slow the rate down
+	MaxRequestsPerRun    int      // hard limit of requests of a run including retries, zero means no limit
+	Proxies              []string // http, https or socks5 proxy urls, requests go direct when empty
+	ProxyStrategy        string   // round_robin, random or sticky per country
+	ProxyCooldownMS      uint64   // how long a failing proxy is benched
+	ProxyMaxFailures     int      // consecutive failures before a proxy is benched
+}
+
+// MarketConfig struct
+type MarketConfig struct {
+	Code            string            // country code used by TipRank, e.g. Canada
+	ISOCountry      string            // ISO 3166-1 alpha-2 country code
+	Currency        string            // ISO 4217 currency code
+	Timezone        string            // IANA timezone of the exchange
+	Calendar        string            // business calendar of the exchange, e.g. NYSE
+	DefaultExchange string            // canonical exchange of tickers without prefix
+	TickerPrefixes  map[string]string // canonical exchange of each prefix TipRank puts in front of tickers, e.g. TSE:
+	TickerPattern   string            // regular expression TipRank tickers of the market match
+	Enabled         bool
 }
 
 // AppConfig struct
 type AppConfig struct {
-	Mongo MongoConfig
+	Mongo   MongoConfig
+	Scraper ScraperConfig
 }
@@ -1,27 +1,50 @@
-// +build dev
-
 package config
 
 import "os"
 
-var host = os.Getenv("MONGO_DB_HOST")
-var username = os.Getenv("MONGO_DB_USERNAME")
-var password = os.Getenv("MONGO_DB_PASSWORD")
-
-// AppConf constants
-var AppConf = AppConfig{
+// devAppConf constants
+var devAppConf = AppConfig{
 	Mongo: MongoConfig{
 		TimeoutMS:     360000,
 		MinPoolSize:   5,
 		MaxPoolSize:   10,
 		MaxIdleTimeMS: 360000,
-		Host:          host,
-		Username:      username,
-		Password:      password,
+		Host:          os.Getenv("MONGO_DB_HOST"),
+		Username:      os.Getenv("MONGO_DB_USERNAME"),
+		Password:      os.Getenv("MONGO_DB_PASSWORD"),
 		Dbname:        "povi",
-		SchemaVersion: "1",
+		SchemaVersion: "4", // latest migration version
 		Colnames: map[string]string{
-			"tiprank_dividend_list": "tiprank_dividend_list",
+			"tiprank_dividend_list":       "tiprank_dividend_list",
+			"tiprank_backfill_plans":      "tiprank_backfill_plans",
+			"tiprank_response_quarantine": "tiprank_response_quarantine",
+			"tiprank_response_hashes":     "tiprank_response_hashes",
+			"tiprank_dividend_rejects":    "tiprank_dividend_rejects",
+			"tiprank_migrations":          "tiprank_migrations",
+			"tiprank_dividend_events":     "tiprank_dividend_events",
 		},
 	},
+	Scraper: ScraperConfig{
+		BaseURL:              BaseURL,
+		DividendPathTemplate: DividendPathTemplate,
+		HistoryPathTemplate:  HistoryPathTemplate,
+		AllowedDomains:       []string{AllowDomain},
+		DomainGlob:           DomainGlob,
+		MaxRetries:           3,
+		RetryBaseDelayMS:     5000,
+		RetryMaxDelayMS:      120000,
+		DeadlineMarginMS:     60000,
+		Parallelism:          2,
+		RandomDelayMS:        2000,
+		RequestsPerSecond:    0.2,
+		MinRequestsPerSecond: 0.05,
+		MaxRequestsPerSecond: 0.5,
+		Burst:                1,
+		LatencyThresholdMS:   5000,
+		MaxRequestsPerRun:    1000,
+		Proxies:              GetProxyList(os.Getenv("SCRAPER_PROXIES")),
+		ProxyStrategy:        "round_robin",
+		ProxyCooldownMS:      300000,
+		ProxyMaxFailures:     3,
+	},
 }
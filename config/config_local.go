@@ -1,9 +1,9 @@
-// +build local
-
 package config
 
-// AppConf constants
-var AppConf = AppConfig{
+import "os"
+
+// localAppConf constants
+var localAppConf = AppConfig{
 	Mongo: MongoConfig{
 		TimeoutMS:     360000,
 		MinPoolSize:   5,
@@ -13,9 +13,38 @@ var AppConf = AppConfig{
 		Username:      "lenoob_dev",
 		Password:      "lenoob_dev",
 		Dbname:        "povi",
-		SchemaVersion: "1",
+		SchemaVersion: "4", // latest migration version
 		Colnames: map[string]string{
-			"tiprank_dividend_list": "tiprank_dividend_list",
+			"tiprank_dividend_list":       "tiprank_dividend_list",
+			"tiprank_backfill_plans":      "tiprank_backfill_plans",
+			"tiprank_response_quarantine": "tiprank_response_quarantine",
+			"tiprank_response_hashes":     "tiprank_response_hashes",
+			"tiprank_dividend_rejects":    "tiprank_dividend_rejects",
+			"tiprank_migrations":          "tiprank_migrations",
+			"tiprank_dividend_events":     "tiprank_dividend_events",
 		},
 	},
+	Scraper: ScraperConfig{
+		BaseURL:              BaseURL,
+		DividendPathTemplate: DividendPathTemplate,
+		HistoryPathTemplate:  HistoryPathTemplate,
+		AllowedDomains:       []string{AllowDomain},
+		DomainGlob:           DomainGlob,
+		MaxRetries:           3,
+		RetryBaseDelayMS:     5000,
+		RetryMaxDelayMS:      120000,
+		DeadlineMarginMS:     60000,
+		Parallelism:          2,
+		RandomDelayMS:        2000,
+		RequestsPerSecond:    0.2,
+		MinRequestsPerSecond: 0.05,
+		MaxRequestsPerSecond: 0.5,
+		Burst:                1,
+		LatencyThresholdMS:   5000,
+		MaxRequestsPerRun:    1000,
+		Proxies:              GetProxyList(os.Getenv("SCRAPER_PROXIES")),
+		ProxyStrategy:        "round_robin",
+		ProxyCooldownMS:      300000,
+		ProxyMaxFailures:     3,
+	},
 }
@@ -0,0 +1,6 @@
+// +build staging
+
+package config
+
+// Env is the environment the binary is built for, its config is used when no environment is selected at run time
+const Env = "staging"
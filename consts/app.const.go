@@ -2,23 +2,11 @@ package consts
 
 // Collection names
 const (
-	TIPRANK_DIVIDEND_LIST_COLLECTION = "tiprank_dividend_list" // Should match with Colnames's key of AppConf
+	TIPRANK_DIVIDEND_LIST_COLLECTION       = "tiprank_dividend_list"       // Should match with Colnames's key of AppConf
+	TIPRANK_BACKFILL_PLAN_COLLECTION       = "tiprank_backfill_plans"      // Should match with Colnames's key of AppConf
+	TIPRANK_RESPONSE_QUARANTINE_COLLECTION = "tiprank_response_quarantine" // Should match with Colnames's key of AppConf
+	TIPRANK_RESPONSE_HASH_COLLECTION       = "tiprank_response_hashes"     // Should match with Colnames's key of AppConf
+	TIPRANK_DIVIDEND_REJECT_COLLECTION     = "tiprank_dividend_rejects"    // Should match with Colnames's key of AppConf
+	TIPRANK_MIGRATION_COLLECTION           = "tiprank_migrations"          // Should match with Colnames's key of AppConf
+	TIPRANK_DIVIDEND_EVENT_COLLECTION      = "tiprank_dividend_events"     // Should match with Colnames's key of AppConf
 )
-
-// TipRank available countries
-// var TipRankCountries = []string{"Canada", "US", "UK"}
-var TipRankCountries = []string{"Canada", "US"}
-
-var Currencies = map[string]struct {
-	Code string
-}{
-	"Canada": {
-		Code: "CAD",
-	},
-	"US": {
-		Code: "USD",
-	},
-	"UK": {
-		Code: "GBP",
-	},
-}
@@ -0,0 +1,61 @@
+package entities
+
+// BackfillStatus is the status of a backfill plan or task
+type BackfillStatus string
+
+// Backfill statuses
+const (
+	BackfillStatusPending   BackfillStatus = "pending"
+	BackfillStatusRunning   BackfillStatus = "running"
+	BackfillStatusDone      BackfillStatus = "done"
+	BackfillStatusFailed    BackfillStatus = "failed"
+	BackfillStatusCancelled BackfillStatus = "cancelled"
+)
+
+// BackfillPlan struct
+type BackfillPlan struct {
+	ID         string          `json:"id,omitempty"`
+	Status     BackfillStatus  `json:"status,omitempty"`
+	From       string          `json:"from,omitempty"`
+	To         string          `json:"to,omitempty"`
+	Countries  []string        `json:"countries,omitempty"`
+	Step       int             `json:"step,omitempty"`
+	Tasks      []*BackfillTask `json:"tasks,omitempty"`
+	CreatedAt  int64           `json:"createdAt,omitempty"`
+	ModifiedAt int64           `json:"modifiedAt,omitempty"`
+	LastRunID  string          `json:"lastRunId,omitempty"`
+}
+
+// BackfillTask struct
+type BackfillTask struct {
+	Country  string         `json:"country,omitempty"`
+	Date     string         `json:"date,omitempty"`
+	Status   BackfillStatus `json:"status,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Attempts int            `json:"attempts,omitempty"`
+}
+
+// CountTasks counts tasks of the plan by status
+func (p *BackfillPlan) CountTasks() map[BackfillStatus]int {
+	counts := map[BackfillStatus]int{}
+	for _, task := range p.Tasks {
+		counts[task.Status]++
+	}
+
+	return counts
+}
+
+// GetRemainingTasks gets tasks of the plan that are not done yet
+func (p *BackfillPlan) GetRemainingTasks() []*ScrapeTask {
+	var tasks []*ScrapeTask
+	for _, task := range p.Tasks {
+		if task.Status != BackfillStatusDone {
+			tasks = append(tasks, &ScrapeTask{
+				Country: task.Country,
+				Date:    task.Date,
+			})
+		}
+	}
+
+	return tasks
+}
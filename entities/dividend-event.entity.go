@@ -0,0 +1,53 @@
+package entities
+
+import "time"
+
+// DividendEvent struct, a dividend of a stock as given by a dividend source
+type DividendEvent struct {
+	Source         string     `json:"source,omitempty"`
+	Country        string     `json:"country,omitempty"` // TipRank country code of the market
+	Ticker         string     `json:"ticker,omitempty"`
+	Name           string     `json:"name,omitempty"`
+	Yield          float64    `json:"yield,omitempty"`
+	Amount         float64    `json:"amount,omitempty"`
+	ExDividendDate *time.Time `json:"exDividendDate,omitempty"`
+	RecordDate     *time.Time `json:"recordDate,omitempty"`
+	DividendDate   *time.Time `json:"payoutDate,omitempty"`
+}
+
+// SourceRunResult struct, what a dividend source gave in a run
+type SourceRunResult struct {
+	Source    string   `json:"source"`
+	Events    int      `json:"events"`
+	Inserted  int      `json:"inserted"`
+	Updated   int      `json:"updated"`
+	Unchanged int      `json:"unchanged"`
+	Rejected  int      `json:"rejected"`
+	Failed    int      `json:"failed"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// tiprankDateLayout is the date layout of TipRank dividends
+const tiprankDateLayout = "2006-01-02T15:04:05"
+
+// ToTipRankDividend converts dividend event to the TipRank dividend shape the dividend service stores
+func (e *DividendEvent) ToTipRankDividend() *TipRankDividend {
+	return &TipRankDividend{
+		Ticker:         e.Ticker,
+		Name:           e.Name,
+		Yield:          e.Yield,
+		Amount:         e.Amount,
+		ExDividendDate: formatTipRankDate(e.ExDividendDate),
+		RecordDate:     formatTipRankDate(e.RecordDate),
+		DividendDate:   formatTipRankDate(e.DividendDate),
+	}
+}
+
+// formatTipRankDate formats an optional date as TipRank does
+func formatTipRankDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+
+	return date.Format(tiprankDateLayout)
+}
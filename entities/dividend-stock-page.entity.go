@@ -0,0 +1,7 @@
+package entities
+
+// DividendStockPage struct, a page of stored stocks, NextCursor is empty on the last page
+type DividendStockPage struct {
+	DividendStocks []*DividendStock `json:"dividendStocks"`
+	NextCursor     string           `json:"nextCursor,omitempty"`
+}
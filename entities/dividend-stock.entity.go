@@ -0,0 +1,27 @@
+package entities
+
+import "time"
+
+// DividendStock struct, a stored stock with its dividend history
+type DividendStock struct {
+	Ticker          string                     `json:"ticker,omitempty"`
+	Exchange        string                     `json:"exchange,omitempty"`
+	Symbol          string                     `json:"symbol,omitempty"`
+	Name            string                     `json:"name,omitempty"`
+	Yield           float64                    `json:"yield,omitempty"`
+	Amount          float64                    `json:"amount,omitempty"`
+	Currency        string                     `json:"currency,omitempty"`
+	CreatedAt       int64                      `json:"createdAt,omitempty"`
+	ModifiedAt      int64                      `json:"modifiedAt,omitempty"`
+	LastRunID       string                     `json:"lastRunId,omitempty"`
+	Version         int64                      `json:"version,omitempty"`
+	DividendHistory map[int64]*DividendHistory `json:"dividendHistory,omitempty"`
+}
+
+// DividendHistory struct
+type DividendHistory struct {
+	Dividend       float64    `json:"dividend,omitempty"`
+	ExDividendDate *time.Time `json:"exDividendDate,omitempty"`
+	RecordDate     *time.Time `json:"recordDate,omitempty"`
+	DividendDate   *time.Time `json:"payoutDate,omitempty"`
+}
@@ -0,0 +1,49 @@
+package entities
+
+// IndexAction is what reconciling an index does, or would do on a dry run
+type IndexAction string
+
+// Index actions
+const (
+	IndexActionUnchanged IndexAction = "unchanged"
+	IndexActionCreate    IndexAction = "create"
+	IndexActionRecreate  IndexAction = "recreate"
+	IndexActionExtra     IndexAction = "extra"
+)
+
+// Migration struct
+type Migration struct {
+	Version   int    `json:"version"`
+	Name      string `json:"name,omitempty"`
+	Applied   bool   `json:"applied"`
+	AppliedAt int64  `json:"appliedAt,omitempty"`
+	RunID     string `json:"runId,omitempty"`
+	Affected  int64  `json:"affected"`
+}
+
+// IndexChange struct
+type IndexChange struct {
+	Collection string      `json:"collection"`
+	Index      string      `json:"index"`
+	Action     IndexAction `json:"action"`
+}
+
+// MigrationReport struct
+type MigrationReport struct {
+	DryRun        bool           `json:"dryRun"`
+	SchemaVersion int            `json:"schemaVersion"`
+	Migrations    []*Migration   `json:"migrations"`
+	Indexes       []*IndexChange `json:"indexes,omitempty"`
+}
+
+// GetPendingMigrations gets migrations which have not been applied yet
+func (r *MigrationReport) GetPendingMigrations() []*Migration {
+	var pending []*Migration
+	for _, migration := range r.Migrations {
+		if !migration.Applied {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending
+}
@@ -0,0 +1,15 @@
+package entities
+
+// QuarantinedResponse struct, a raw TipRank response kept aside because its shape drifted. A dividend response
+// has a date, a dividend history response has a ticker
+type QuarantinedResponse struct {
+	Country string         `json:"country,omitempty"`
+	Date    string         `json:"date,omitempty"`
+	Ticker  string         `json:"ticker,omitempty"`
+	URL     string         `json:"url,omitempty"`
+	RunID   string         `json:"runId,omitempty"`
+	Reason  string         `json:"reason,omitempty"`
+	Drift   *ResponseDrift `json:"drift,omitempty"`
+	Body    []byte         `json:"body,omitempty"`
+	Hash    string         `json:"hash,omitempty"`
+}
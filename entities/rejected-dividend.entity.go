@@ -0,0 +1,9 @@
+package entities
+
+// RejectedDividend struct, a TipRank dividend kept aside because it failed validation
+type RejectedDividend struct {
+	Country string           `json:"country,omitempty"`
+	RunID   string           `json:"runId,omitempty"`
+	Reasons []string         `json:"reasons,omitempty"`
+	Record  *TipRankDividend `json:"record,omitempty"`
+}
@@ -0,0 +1,45 @@
+package entities
+
+// ResponseDrift describes how a TipRank response differs from the shape we expect
+type ResponseDrift struct {
+	NonJSON        bool           `json:"nonJson,omitempty"`
+	UnknownFields  map[string]int `json:"unknownFields,omitempty"`  // field name to number of records having it
+	MissingFields  map[string]int `json:"missingFields,omitempty"`  // required field name to number of records missing it
+	TypeMismatches map[string]int `json:"typeMismatches,omitempty"` // field name to number of records with an unexpected type
+}
+
+// NewResponseDrift creates empty response drift
+func NewResponseDrift() *ResponseDrift {
+	return &ResponseDrift{
+		UnknownFields:  map[string]int{},
+		MissingFields:  map[string]int{},
+		TypeMismatches: map[string]int{},
+	}
+}
+
+// HasDrift checks whether anything differs from the expected shape
+func (d *ResponseDrift) HasDrift() bool {
+	return d.NonJSON || len(d.UnknownFields) > 0 || len(d.MissingFields) > 0 || len(d.TypeMismatches) > 0
+}
+
+// IsBreaking checks whether the drift makes some data unusable, new fields alone are not breaking
+func (d *ResponseDrift) IsBreaking() bool {
+	return d.NonJSON || len(d.MissingFields) > 0 || len(d.TypeMismatches) > 0
+}
+
+// Merge adds counts of another drift
+func (d *ResponseDrift) Merge(other *ResponseDrift) {
+	d.NonJSON = d.NonJSON || other.NonJSON
+
+	for k, v := range other.UnknownFields {
+		d.UnknownFields[k] += v
+	}
+
+	for k, v := range other.MissingFields {
+		d.MissingFields[k] += v
+	}
+
+	for k, v := range other.TypeMismatches {
+		d.TypeMismatches[k] += v
+	}
+}
@@ -0,0 +1,7 @@
+package entities
+
+// ScrapeTask struct, a single (country, date) request to scrape
+type ScrapeTask struct {
+	Country string `json:"country"`
+	Date    string `json:"date"` // formatted as 2006-01-02
+}
@@ -0,0 +1,12 @@
+package entities
+
+// Symbol struct, canonical listing of a stock on an exchange
+type Symbol struct {
+	Exchange string `json:"exchange"`
+	Symbol   string `json:"symbol"`
+}
+
+// String formats symbol as EXCHANGE:SYMBOL
+func (s *Symbol) String() string {
+	return s.Exchange + ":" + s.Symbol
+}
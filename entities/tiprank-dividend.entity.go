@@ -10,3 +10,10 @@ type TipRankDividend struct {
 	RecordDate     string  `json:"recDate,omitempty"`
 	DividendDate   string  `json:"payDate,omitempty"`
 }
+
+// TipRankDividendWrite struct, a TipRank dividend to write with the canonical symbol and currency of its stock
+type TipRankDividendWrite struct {
+	TipRankDividend *TipRankDividend
+	Symbol          *Symbol
+	Currency        string
+}
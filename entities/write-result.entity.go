@@ -0,0 +1,12 @@
+package entities
+
+// WriteResult describes what a write did to a stored document
+type WriteResult string
+
+// Write results
+const (
+	WriteResultInserted  WriteResult = "inserted"
+	WriteResultUpdated   WriteResult = "updated"
+	WriteResultUnchanged WriteResult = "unchanged"
+	WriteResultRejected  WriteResult = "rejected" // the dividend failed validation and was kept aside
+)
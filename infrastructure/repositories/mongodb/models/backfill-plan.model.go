@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BackfillPlanModel struct
+type BackfillPlanModel struct {
+	ID         *primitive.ObjectID  `bson:"_id,omitempty"`
+	CreatedAt  int64                `bson:"createdAt,omitempty"`
+	ModifiedAt int64                `bson:"modifiedAt,omitempty"`
+	Schema     string               `bson:"schema,omitempty"`
+	LastRunID  string               `bson:"lastRunId,omitempty"`
+	Status     string               `bson:"status,omitempty"`
+	From       string               `bson:"from,omitempty"`
+	To         string               `bson:"to,omitempty"`
+	Countries  []string             `bson:"countries,omitempty"`
+	Step       int                  `bson:"step,omitempty"`
+	Tasks      []*BackfillTaskModel `bson:"tasks,omitempty"`
+}
+
+// BackfillTaskModel struct
+type BackfillTaskModel struct {
+	Country  string `bson:"country,omitempty"`
+	Date     string `bson:"date,omitempty"`
+	Status   string `bson:"status,omitempty"`
+	Error    string `bson:"error,omitempty"`
+	Attempts int    `bson:"attempts,omitempty"`
+}
+
+// NewBackfillPlanModel create backfill plan model
+func NewBackfillPlanModel(plan *entities.BackfillPlan, runID string, schemaVersion string) *BackfillPlanModel {
+	var backfillPlanModel = &BackfillPlanModel{
+		CreatedAt:  time.Now().UTC().Unix(),
+		ModifiedAt: time.Now().UTC().Unix(),
+		Schema:     schemaVersion,
+		LastRunID:  runID,
+		Status:     string(plan.Status),
+		From:       plan.From,
+		To:         plan.To,
+		Countries:  plan.Countries,
+		Step:       plan.Step,
+	}
+
+	for _, task := range plan.Tasks {
+		backfillPlanModel.Tasks = append(backfillPlanModel.Tasks, &BackfillTaskModel{
+			Country:  task.Country,
+			Date:     task.Date,
+			Status:   string(task.Status),
+			Error:    task.Error,
+			Attempts: task.Attempts,
+		})
+	}
+
+	return backfillPlanModel
+}
+
+// ToEntity converts backfill plan model to entity
+func (m *BackfillPlanModel) ToEntity() *entities.BackfillPlan {
+	plan := &entities.BackfillPlan{
+		Status:     entities.BackfillStatus(m.Status),
+		From:       m.From,
+		To:         m.To,
+		Countries:  m.Countries,
+		Step:       m.Step,
+		CreatedAt:  m.CreatedAt,
+		ModifiedAt: m.ModifiedAt,
+		LastRunID:  m.LastRunID,
+	}
+
+	if m.ID != nil {
+		plan.ID = m.ID.Hex()
+	}
+
+	for _, task := range m.Tasks {
+		plan.Tasks = append(plan.Tasks, &entities.BackfillTask{
+			Country:  task.Country,
+			Date:     task.Date,
+			Status:   entities.BackfillStatus(task.Status),
+			Error:    task.Error,
+			Attempts: task.Attempts,
+		})
+	}
+
+	return plan
+}
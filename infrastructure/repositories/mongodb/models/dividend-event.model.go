@@ -0,0 +1,127 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DividendEventModel struct, one dividend of a stock keyed on exchange, symbol and ex-dividend date.
+// It copies an event of the dividend history of the stock so that dividend dates can be indexed
+type DividendEventModel struct {
+	ID             *primitive.ObjectID `bson:"_id,omitempty"`
+	CreatedAt      int64               `bson:"createdAt,omitempty"`
+	ModifiedAt     int64               `bson:"modifiedAt,omitempty"`
+	Schema         string              `bson:"schema,omitempty"`
+	LastRunID      string              `bson:"lastRunId,omitempty"`
+	Ticker         string              `bson:"ticker,omitempty"`
+	Exchange       string              `bson:"exchange,omitempty"`
+	Symbol         string              `bson:"symbol,omitempty"`
+	Name           string              `bson:"name,omitempty"`
+	Currency       string              `bson:"currency,omitempty"`
+	Dividend       float64             `bson:"dividend,omitempty"`
+	ExDividendDate *time.Time          `bson:"exDividendDate,omitempty"`
+	RecordDate     *time.Time          `bson:"recordDate,omitempty"`
+	DividendDate   *time.Time          `bson:"payoutDate,omitempty"`
+	Hash           string              `bson:"hash,omitempty"`
+}
+
+// NewDividendEventModels create dividend event models of every event of the dividend history of a stock,
+// ordered by ex-dividend date. A legacy stock without symbol has none until it is migrated
+func NewDividendEventModels(tiprankDividendModel *TipRankDividendModel, schemaVersion string) []*DividendEventModel {
+	if tiprankDividendModel.Exchange == "" || tiprankDividendModel.Symbol == "" {
+		return nil
+	}
+
+	var dividendTimes []int64
+	for k := range tiprankDividendModel.DividendHistory {
+		dividendTimes = append(dividendTimes, k)
+	}
+	sort.Slice(dividendTimes, func(i, j int) bool { return dividendTimes[i] < dividendTimes[j] })
+
+	var dividendEventModels []*DividendEventModel
+	for _, k := range dividendTimes {
+		dividendHistoryModel := tiprankDividendModel.DividendHistory[k]
+		if dividendHistoryModel == nil || dividendHistoryModel.ExDividendDate == nil {
+			continue
+		}
+
+		dividendEventModels = append(dividendEventModels, &DividendEventModel{
+			ModifiedAt:     time.Now().UTC().Unix(),
+			Schema:         schemaVersion,
+			LastRunID:      tiprankDividendModel.LastRunID,
+			Ticker:         tiprankDividendModel.Ticker,
+			Exchange:       tiprankDividendModel.Exchange,
+			Symbol:         tiprankDividendModel.Symbol,
+			Name:           tiprankDividendModel.Name,
+			Currency:       tiprankDividendModel.Currency,
+			Dividend:       dividendHistoryModel.Dividend,
+			ExDividendDate: dividendHistoryModel.ExDividendDate,
+			RecordDate:     dividendHistoryModel.RecordDate,
+			DividendDate:   dividendHistoryModel.DividendDate,
+			Hash:           dividendHistoryModel.Hash,
+		})
+	}
+
+	return dividendEventModels
+}
+
+// GetFilter gets filter of the saved dividend event, exchange, symbol and ex-dividend date identify an event
+// since the ticker TipRank gives a stock may change
+func (m *DividendEventModel) GetFilter() bson.D {
+	return bson.D{
+		{
+			Key:   "exchange",
+			Value: m.Exchange,
+		},
+		{
+			Key:   "symbol",
+			Value: m.Symbol,
+		},
+		{
+			Key:   "exDividendDate",
+			Value: m.ExDividendDate,
+		},
+	}
+}
+
+// GetUpdate gets update of the dividend event, the whole event is $set except its creation time
+func (m *DividendEventModel) GetUpdate() bson.D {
+	return bson.D{
+		{
+			Key: "$set",
+			Value: bson.D{
+				{Key: "modifiedAt", Value: m.ModifiedAt},
+				{Key: "schema", Value: m.Schema},
+				{Key: "lastRunId", Value: m.LastRunID},
+				{Key: "ticker", Value: m.Ticker},
+				{Key: "name", Value: m.Name},
+				{Key: "currency", Value: m.Currency},
+				{Key: "dividend", Value: m.Dividend},
+				{Key: "recordDate", Value: m.RecordDate},
+				{Key: "payoutDate", Value: m.DividendDate},
+				{Key: "hash", Value: m.Hash},
+			},
+		},
+		{
+			Key: "$setOnInsert",
+			Value: bson.D{{
+				Key:   "createdAt",
+				Value: time.Now().UTC().Unix(),
+			}},
+		},
+	}
+}
+
+// ToDividendHistoryModel converts dividend event model to an event of a dividend history
+func (m *DividendEventModel) ToDividendHistoryModel() *DividendHistoryModel {
+	return &DividendHistoryModel{
+		Dividend:       m.Dividend,
+		ExDividendDate: m.ExDividendDate,
+		RecordDate:     m.RecordDate,
+		DividendDate:   m.DividendDate,
+		Hash:           m.Hash,
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MigrationModel struct
+type MigrationModel struct {
+	ID        *primitive.ObjectID `bson:"_id,omitempty"`
+	Version   int                 `bson:"version"`
+	Name      string              `bson:"name,omitempty"`
+	AppliedAt int64               `bson:"appliedAt,omitempty"`
+	RunID     string              `bson:"runId,omitempty"`
+	Affected  int64               `bson:"affected"`
+}
+
+// NewMigrationModel create applied migration model
+func NewMigrationModel(migration *entities.Migration, runID string) *MigrationModel {
+	return &MigrationModel{
+		Version:   migration.Version,
+		Name:      migration.Name,
+		AppliedAt: time.Now().UTC().Unix(),
+		RunID:     runID,
+		Affected:  migration.Affected,
+	}
+}
+
+// ToEntity converts migration model to entity
+func (m *MigrationModel) ToEntity() *entities.Migration {
+	return &entities.Migration{
+		Version:   m.Version,
+		Name:      m.Name,
+		Applied:   true,
+		AppliedAt: m.AppliedAt,
+		RunID:     m.RunID,
+		Affected:  m.Affected,
+	}
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxQuarantinedBodySize keeps quarantined documents far below the 16MB document limit
+const maxQuarantinedBodySize = 4 << 20
+
+// QuarantinedResponseModel struct, a response quarantined again by later runs is kept once and counted
+type QuarantinedResponseModel struct {
+	ID            *primitive.ObjectID `bson:"_id,omitempty"`
+	CreatedAt     int64               `bson:"createdAt,omitempty"`
+	ModifiedAt    int64               `bson:"modifiedAt,omitempty"`
+	Schema        string              `bson:"schema,omitempty"`
+	Country       string              `bson:"country,omitempty"`
+	Date          string              `bson:"date,omitempty"`
+	Ticker        string              `bson:"ticker,omitempty"`
+	URL           string              `bson:"url,omitempty"`
+	RunID         string              `bson:"runId,omitempty"`
+	Reason        string              `bson:"reason,omitempty"`
+	Drift         *ResponseDriftModel `bson:"drift,omitempty"`
+	Body          string              `bson:"body,omitempty"`
+	BodyTruncated bool                `bson:"bodyTruncated,omitempty"`
+	Hash          string              `bson:"hash,omitempty"`
+	Occurrences   int                 `bson:"occurrences,omitempty"`
+}
+
+// ResponseDriftModel struct
+type ResponseDriftModel struct {
+	NonJSON        bool           `bson:"nonJson,omitempty"`
+	UnknownFields  map[string]int `bson:"unknownFields,omitempty"`
+	MissingFields  map[string]int `bson:"missingFields,omitempty"`
+	TypeMismatches map[string]int `bson:"typeMismatches,omitempty"`
+}
+
+// NewQuarantinedResponseModel create quarantined response model
+func NewQuarantinedResponseModel(quarantinedResponse *entities.QuarantinedResponse, schemaVersion string) *QuarantinedResponseModel {
+	var quarantinedResponseModel = &QuarantinedResponseModel{
+		ModifiedAt: time.Now().UTC().Unix(),
+		Schema:     schemaVersion,
+		Country:    quarantinedResponse.Country,
+		Date:       quarantinedResponse.Date,
+		Ticker:     quarantinedResponse.Ticker,
+		URL:        quarantinedResponse.URL,
+		RunID:      quarantinedResponse.RunID,
+		Reason:     quarantinedResponse.Reason,
+		Body:       string(quarantinedResponse.Body),
+		Hash:       quarantinedResponse.Hash,
+	}
+
+	if len(quarantinedResponse.Body) > maxQuarantinedBodySize {
+		quarantinedResponseModel.Body = string(quarantinedResponse.Body[:maxQuarantinedBodySize])
+		quarantinedResponseModel.BodyTruncated = true
+	}
+
+	if drift := quarantinedResponse.Drift; drift != nil {
+		quarantinedResponseModel.Drift = &ResponseDriftModel{
+			NonJSON:        drift.NonJSON,
+			UnknownFields:  drift.UnknownFields,
+			MissingFields:  drift.MissingFields,
+			TypeMismatches: drift.TypeMismatches,
+		}
+	}
+
+	return quarantinedResponseModel
+}
+
+// GetFilter gets filter of the saved quarantined response, a dividend response is identified by its country,
+// date and content hash, a dividend history response by its country, ticker and content hash
+func (m *QuarantinedResponseModel) GetFilter() bson.D {
+	filter := bson.D{
+		{
+			Key:   "country",
+			Value: m.Country,
+		},
+	}
+
+	if m.Ticker != "" {
+		filter = append(filter, bson.E{Key: "ticker", Value: m.Ticker})
+	} else {
+		filter = append(filter, bson.E{Key: "date", Value: m.Date})
+	}
+
+	return append(filter, bson.E{Key: "hash", Value: m.Hash})
+}
+
+// GetUpdate gets update of the quarantined response, the body is only set when it is first quarantined
+// and every quarantine is counted
+func (m *QuarantinedResponseModel) GetUpdate() bson.D {
+	return bson.D{
+		{
+			Key: "$set",
+			Value: bson.D{
+				{Key: "modifiedAt", Value: m.ModifiedAt},
+				{Key: "schema", Value: m.Schema},
+				{Key: "url", Value: m.URL},
+				{Key: "runId", Value: m.RunID},
+				{Key: "reason", Value: m.Reason},
+				{Key: "drift", Value: m.Drift},
+			},
+		},
+		{
+			Key: "$inc",
+			Value: bson.D{{
+				Key:   "occurrences",
+				Value: 1,
+			}},
+		},
+		{
+			Key: "$setOnInsert",
+			Value: bson.D{
+				{Key: "createdAt", Value: time.Now().UTC().Unix()},
+				{Key: "body", Value: m.Body},
+				{Key: "bodyTruncated", Value: m.BodyTruncated},
+			},
+		},
+	}
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+)
+
+func TestQuarantinedResponseModelGetFilter(t *testing.T) {
+	tests := []struct {
+		name                string
+		quarantinedResponse *entities.QuarantinedResponse
+		wantKeys            []string
+	}{
+		{
+			name:                "dividend response",
+			quarantinedResponse: &entities.QuarantinedResponse{Country: "Canada", Date: "2021-03-01", Hash: "abc"},
+			wantKeys:            []string{"country", "date", "hash"},
+		},
+		{
+			name:                "dividend history response",
+			quarantinedResponse: &entities.QuarantinedResponse{Country: "Canada", Ticker: "TSE:RY", Hash: "abc"},
+			wantKeys:            []string{"country", "ticker", "hash"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var keys []string
+			for _, e := range NewQuarantinedResponseModel(tt.quarantinedResponse, "2").GetFilter() {
+				keys = append(keys, e.Key)
+			}
+
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("filter keys = %v, want %v", keys, tt.wantKeys)
+			}
+		})
+	}
+}
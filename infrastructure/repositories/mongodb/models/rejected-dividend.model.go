@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RejectedDividendModel struct
+type RejectedDividendModel struct {
+	ID        *primitive.ObjectID  `bson:"_id,omitempty"`
+	CreatedAt int64                `bson:"createdAt,omitempty"`
+	Schema    string               `bson:"schema,omitempty"`
+	Country   string               `bson:"country,omitempty"`
+	Ticker    string               `bson:"ticker,omitempty"`
+	RunID     string               `bson:"runId,omitempty"`
+	Reasons   []string             `bson:"reasons,omitempty"`
+	Record    *RejectedRecordModel `bson:"record,omitempty"`
+}
+
+// RejectedRecordModel struct, the raw TipRank dividend as it was received
+type RejectedRecordModel struct {
+	Ticker         string  `bson:"ticker"`
+	Name           string  `bson:"company"`
+	Yield          float64 `bson:"yield"`
+	Amount         float64 `bson:"amount"`
+	ExDividendDate string  `bson:"exDate"`
+	RecordDate     string  `bson:"recDate"`
+	DividendDate   string  `bson:"payDate"`
+}
+
+// NewRejectedDividendModel create rejected dividend model
+func NewRejectedDividendModel(rejectedDividend *entities.RejectedDividend, schemaVersion string) *RejectedDividendModel {
+	var rejectedDividendModel = &RejectedDividendModel{
+		CreatedAt: time.Now().UTC().Unix(),
+		Schema:    schemaVersion,
+		Country:   rejectedDividend.Country,
+		RunID:     rejectedDividend.RunID,
+		Reasons:   rejectedDividend.Reasons,
+	}
+
+	if record := rejectedDividend.Record; record != nil {
+		rejectedDividendModel.Ticker = record.Ticker
+		rejectedDividendModel.Record = &RejectedRecordModel{
+			Ticker:         record.Ticker,
+			Name:           record.Name,
+			Yield:          record.Yield,
+			Amount:         record.Amount,
+			ExDividendDate: record.ExDividendDate,
+			RecordDate:     record.RecordDate,
+			DividendDate:   record.DividendDate,
+		}
+	}
+
+	return rejectedDividendModel
+}
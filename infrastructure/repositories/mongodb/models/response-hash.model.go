@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// ResponseHashModel struct, content hash of the last processed TipRank response of a (country, date)
+type ResponseHashModel struct {
+	ModifiedAt int64  `bson:"modifiedAt,omitempty"`
+	Schema     string `bson:"schema,omitempty"`
+	LastRunID  string `bson:"lastRunId,omitempty"`
+	Country    string `bson:"country,omitempty"`
+	Date       string `bson:"date,omitempty"`
+	Hash       string `bson:"hash,omitempty"`
+}
+
+// NewResponseHashModel create response hash model
+func NewResponseHashModel(country string, date string, hash string, runID string, schemaVersion string) *ResponseHashModel {
+	return &ResponseHashModel{
+		ModifiedAt: time.Now().UTC().Unix(),
+		Schema:     schemaVersion,
+		LastRunID:  runID,
+		Country:    country,
+		Date:       date,
+		Hash:       hash,
+	}
+}
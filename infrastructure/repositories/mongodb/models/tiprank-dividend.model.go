@@ -2,14 +2,19 @@ package models
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"time"
 
 	logger "github.com/lenoobz/aws-lambda-logger"
 	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
 	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/datetime"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/hash"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// TipRankDividendModel struct
 type TipRankDividendModel struct {
 	ID              *primitive.ObjectID             `bson:"_id,omitempty"`
 	CreatedAt       int64                           `bson:"createdAt,omitempty"`
@@ -17,7 +22,11 @@ type TipRankDividendModel struct {
 	Enabled         bool                            `bson:"enabled"`
 	Deleted         bool                            `bson:"deleted"`
 	Schema          string                          `bson:"schema,omitempty"`
+	LastRunID       string                          `bson:"lastRunId,omitempty"`
+	Version         int64                           `bson:"version,omitempty"` // incremented by every write, legacy documents have none
 	Ticker          string                          `bson:"ticker,omitempty"`
+	Exchange        string                          `bson:"exchange,omitempty"`
+	Symbol          string                          `bson:"symbol,omitempty"`
 	Name            string                          `bson:"name,omitempty"`
 	Yield           float64                         `bson:"yield,omitempty"`
 	Amount          float64                         `bson:"amount,omitempty"`
@@ -31,16 +40,19 @@ type DividendHistoryModel struct {
 	ExDividendDate *time.Time `bson:"exDividendDate,omitempty"`
 	RecordDate     *time.Time `bson:"recordDate,omitempty"`
 	DividendDate   *time.Time `bson:"payoutDate,omitempty"`
+	Hash           string     `bson:"hash,omitempty"`
 }
 
-// NewTipRankDividendModel create stock model
-func NewTipRankDividendModel(ctx context.Context, log logger.ContextLog, tiprankDividend *entities.TipRankDividend, currency string, schemaVersion string) (*TipRankDividendModel, error) {
+// NewTipRankDividendModel create stock model, it fails when the dividend has no valid ex-dividend date
+func NewTipRankDividendModel(ctx context.Context, log logger.ContextLog, tiprankDividend *entities.TipRankDividend, symbol *entities.Symbol, currency string, schemaVersion string) (*TipRankDividendModel, error) {
 	var tiprankDividendModel = &TipRankDividendModel{
 		ModifiedAt:      time.Now().UTC().Unix(),
 		Enabled:         true,
 		Deleted:         false,
 		Schema:          schemaVersion,
 		Ticker:          tiprankDividend.Ticker,
+		Exchange:        symbol.Exchange,
+		Symbol:          symbol.Symbol,
 		Name:            tiprankDividend.Name,
 		Yield:           tiprankDividend.Yield,
 		Amount:          tiprankDividend.Amount,
@@ -50,13 +62,69 @@ func NewTipRankDividendModel(ctx context.Context, log logger.ContextLog, tiprank
 
 	dividendHistoryModel, err := newDividendHistoryModel(ctx, log, tiprankDividend)
 
-	tiprankDividendModel.DividendHistory = make(map[int64]*DividendHistoryModel)
+	// dividend history is keyed on ex-dividend time
+	if dividendHistoryModel.ExDividendDate == nil {
+		return nil, fmt.Errorf("dividend of %s has no valid exDividendDate %q", tiprankDividend.Ticker, tiprankDividend.ExDividendDate)
+	}
+
 	dividendTime := dividendHistoryModel.ExDividendDate.Unix()
 	tiprankDividendModel.DividendHistory[dividendTime] = dividendHistoryModel
 
 	return tiprankDividendModel, err
 }
 
+// NewTipRankDividendHistoryModel create stock model from dividend history of a ticker,
+// top level fields come from the latest dividend and dividends without ex-dividend date are skipped
+func NewTipRankDividendHistoryModel(ctx context.Context, log logger.ContextLog, ticker string, symbol *entities.Symbol, tiprankDividends []*entities.TipRankDividend, currency string, schemaVersion string) *TipRankDividendModel {
+	var tiprankDividendModel = &TipRankDividendModel{
+		ModifiedAt:      time.Now().UTC().Unix(),
+		Enabled:         true,
+		Deleted:         false,
+		Schema:          schemaVersion,
+		Ticker:          ticker,
+		Exchange:        symbol.Exchange,
+		Symbol:          symbol.Symbol,
+		Currency:        currency,
+		DividendHistory: map[int64]*DividendHistoryModel{},
+	}
+
+	var latestDividendTime int64
+	for _, tiprankDividend := range tiprankDividends {
+		dividendHistoryModel, _ := newDividendHistoryModel(ctx, log, tiprankDividend)
+		if dividendHistoryModel.ExDividendDate == nil {
+			log.Warn(ctx, "skip dividend without exDividendDate", "ticker", ticker, "exDividendDate", tiprankDividend.ExDividendDate)
+			continue
+		}
+
+		dividendTime := dividendHistoryModel.ExDividendDate.Unix()
+		tiprankDividendModel.DividendHistory[dividendTime] = dividendHistoryModel
+
+		if tiprankDividendModel.Name == "" {
+			tiprankDividendModel.Name = tiprankDividend.Name
+		}
+
+		if dividendTime >= latestDividendTime {
+			latestDividendTime = dividendTime
+			tiprankDividendModel.Yield = tiprankDividend.Yield
+			tiprankDividendModel.Amount = tiprankDividend.Amount
+		}
+	}
+
+	return tiprankDividendModel
+}
+
+// GetLatestDividendTime gets ex-dividend time of the latest dividend in history
+func (m *TipRankDividendModel) GetLatestDividendTime() int64 {
+	var latestDividendTime int64
+	for k := range m.DividendHistory {
+		if k > latestDividendTime {
+			latestDividendTime = k
+		}
+	}
+
+	return latestDividendTime
+}
+
 // newDividendHistoryModel create dividend history model
 func newDividendHistoryModel(ctx context.Context, log logger.ContextLog, tiprankDividend *entities.TipRankDividend) (*DividendHistoryModel, error) {
 	dividendHistoryModel := &DividendHistoryModel{
@@ -84,5 +152,138 @@ func newDividendHistoryModel(ctx context.Context, log logger.ContextLog, tiprank
 		dividendHistoryModel.DividendDate = dividendDate
 	}
 
+	dividendHistoryModel.Hash = dividendHistoryModel.getContentHash()
+
 	return dividendHistoryModel, err
 }
+
+// getContentHash gets content hash of the dividend event
+func (m *DividendHistoryModel) getContentHash() string {
+	content := fmt.Sprintf("%v|%s|%s|%s", m.Dividend, formatDate(m.ExDividendDate), formatDate(m.RecordDate), formatDate(m.DividendDate))
+	return hash.GetContentHash([]byte(content))
+}
+
+// HasChanges checks whether writing the model changes a saved model, ignoring timestamps, run id and version.
+// Dividend events are compared by content hash, saved events the model does not have are kept by the write
+func (m *TipRankDividendModel) HasChanges(saved *TipRankDividendModel) bool {
+	if saved == nil {
+		return true
+	}
+
+	if m.Ticker != saved.Ticker || m.Exchange != saved.Exchange || m.Symbol != saved.Symbol || m.Name != saved.Name ||
+		m.Yield != saved.Yield || m.Amount != saved.Amount || m.Currency != saved.Currency || m.Schema != saved.Schema ||
+		m.Enabled != saved.Enabled || m.Deleted != saved.Deleted {
+		return true
+	}
+
+	for k, v := range m.DividendHistory {
+		savedDividendHistory, found := saved.DividendHistory[k]
+		if !found || savedDividendHistory.Hash != v.Hash {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetUpdate gets update of the model. Top level fields are $set as a whole, empty ones are left as saved,
+// but each dividend event is $set on its own dividendHistory.<ex-dividend time> key so that concurrent
+// writers of other events of the same stock never clobber each other. The version is incremented
+func (m *TipRankDividendModel) GetUpdate() bson.D {
+	fields := bson.D{
+		{Key: "modifiedAt", Value: m.ModifiedAt},
+		{Key: "enabled", Value: m.Enabled},
+		{Key: "deleted", Value: m.Deleted},
+	}
+
+	for _, field := range []bson.E{
+		{Key: "schema", Value: m.Schema},
+		{Key: "lastRunId", Value: m.LastRunID},
+		{Key: "ticker", Value: m.Ticker},
+		{Key: "exchange", Value: m.Exchange},
+		{Key: "symbol", Value: m.Symbol},
+		{Key: "name", Value: m.Name},
+		{Key: "currency", Value: m.Currency},
+	} {
+		if field.Value != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	if m.Yield != 0 {
+		fields = append(fields, bson.E{Key: "yield", Value: m.Yield})
+	}
+
+	if m.Amount != 0 {
+		fields = append(fields, bson.E{Key: "amount", Value: m.Amount})
+	}
+
+	var dividendTimes []int64
+	for k := range m.DividendHistory {
+		dividendTimes = append(dividendTimes, k)
+	}
+	sort.Slice(dividendTimes, func(i, j int) bool { return dividendTimes[i] < dividendTimes[j] })
+
+	for _, k := range dividendTimes {
+		fields = append(fields, bson.E{Key: fmt.Sprintf("dividendHistory.%d", k), Value: m.DividendHistory[k]})
+	}
+
+	return bson.D{
+		{
+			Key:   "$set",
+			Value: fields,
+		},
+		{
+			Key: "$inc",
+			Value: bson.D{{
+				Key:   "version",
+				Value: 1,
+			}},
+		},
+		{
+			Key: "$setOnInsert",
+			Value: bson.D{{
+				Key:   "createdAt",
+				Value: time.Now().UTC().Unix(),
+			}},
+		},
+	}
+}
+
+// formatDate formats an optional date as 2006-01-02
+func formatDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+
+	return date.Format("2006-01-02")
+}
+
+// ToEntity converts TipRank dividend model to entity
+func (m *TipRankDividendModel) ToEntity() *entities.DividendStock {
+	dividendStock := &entities.DividendStock{
+		Ticker:          m.Ticker,
+		Exchange:        m.Exchange,
+		Symbol:          m.Symbol,
+		Name:            m.Name,
+		Yield:           m.Yield,
+		Amount:          m.Amount,
+		Currency:        m.Currency,
+		CreatedAt:       m.CreatedAt,
+		ModifiedAt:      m.ModifiedAt,
+		LastRunID:       m.LastRunID,
+		Version:         m.Version,
+		DividendHistory: map[int64]*entities.DividendHistory{},
+	}
+
+	for k, v := range m.DividendHistory {
+		dividendStock.DividendHistory[k] = &entities.DividendHistory{
+			Dividend:       v.Dividend,
+			ExDividendDate: v.ExDividendDate,
+			RecordDate:     v.RecordDate,
+			DividendDate:   v.DividendDate,
+		}
+	}
+
+	return dividendStock
+}
@@ -0,0 +1,224 @@
+package models
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	logger "github.com/lenoobz/aws-lambda-logger"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// newTestTipRankDividendModel creates stock model of TSX:RY with dividends of given ex-dividend dates and amounts
+func newTestTipRankDividendModel(t *testing.T, dividends map[string]float64) *TipRankDividendModel {
+	log, err := logger.NewZapLogger()
+	if err != nil {
+		t.Fatalf("create logger failed: %v", err)
+	}
+
+	var tiprankDividends []*entities.TipRankDividend
+	for exDividendDate, amount := range dividends {
+		tiprankDividends = append(tiprankDividends, &entities.TipRankDividend{
+			Ticker:         "TSE:RY",
+			Name:           "Royal Bank of Canada",
+			Amount:         amount,
+			ExDividendDate: exDividendDate + "T00:00:00",
+		})
+	}
+
+	symbol := &entities.Symbol{Exchange: "TSX", Symbol: "RY"}
+	return NewTipRankDividendHistoryModel(context.Background(), log, "TSE:RY", symbol, tiprankDividends, "CAD", "2")
+}
+
+func TestNewTipRankDividendModel(t *testing.T) {
+	log, err := logger.NewZapLogger()
+	if err != nil {
+		t.Fatalf("create logger failed: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		exDividendDate string
+		wantTime       int64
+		wantErr        bool
+	}{
+		{name: "keyed on ex-dividend time", exDividendDate: "2021-03-01T12:30:00", wantTime: 1614556800},
+		{name: "invalid ex-dividend date", exDividendDate: "2021-03-01", wantErr: true},
+		{name: "missing ex-dividend date", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiprankDividend := &entities.TipRankDividend{
+				Ticker:         "TSE:RY",
+				Amount:         1.08,
+				ExDividendDate: tt.exDividendDate,
+			}
+
+			model, err := NewTipRankDividendModel(context.Background(), log, tiprankDividend, &entities.Symbol{Exchange: "TSX", Symbol: "RY"}, "CAD", "2")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewTipRankDividendModel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				if model != nil {
+					t.Errorf("NewTipRankDividendModel() = %v, want no model", model)
+				}
+				return
+			}
+
+			if len(model.DividendHistory) != 1 || model.DividendHistory[tt.wantTime] == nil {
+				t.Errorf("DividendHistory = %v, want a single dividend at %d", model.DividendHistory, tt.wantTime)
+			}
+		})
+	}
+}
+
+func TestTipRankDividendModelHasChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		model  map[string]float64
+		saved  map[string]float64
+		change func(saved *TipRankDividendModel)
+		want   bool
+	}{
+		{
+			name:  "not saved",
+			model: map[string]float64{"2021-03-01": 1.08},
+			want:  true,
+		},
+		{
+			name:  "same dividends",
+			model: map[string]float64{"2021-03-01": 1.08},
+			saved: map[string]float64{"2021-03-01": 1.08},
+		},
+		{
+			name:  "timestamps, run id and version are ignored",
+			model: map[string]float64{"2021-03-01": 1.08},
+			saved: map[string]float64{"2021-03-01": 1.08},
+			change: func(saved *TipRankDividendModel) {
+				saved.ModifiedAt = 1
+				saved.CreatedAt = 1
+				saved.LastRunID = "previous"
+				saved.Version = 7
+			},
+		},
+		{
+			name:  "saved dividends the model does not have are kept",
+			model: map[string]float64{"2021-03-01": 1.08},
+			saved: map[string]float64{"2020-12-01": 1.08, "2021-03-01": 1.08},
+		},
+		{
+			name:  "new dividend",
+			model: map[string]float64{"2020-12-01": 1.08, "2021-03-01": 1.08},
+			saved: map[string]float64{"2021-03-01": 1.08},
+			want:  true,
+		},
+		{
+			name:  "changed dividend",
+			model: map[string]float64{"2021-03-01": 1.09},
+			saved: map[string]float64{"2021-03-01": 1.08},
+			want:  true,
+		},
+		{
+			name:   "changed name",
+			model:  map[string]float64{"2021-03-01": 1.08},
+			saved:  map[string]float64{"2021-03-01": 1.08},
+			change: func(saved *TipRankDividendModel) { saved.Name = "Royal Bank" },
+			want:   true,
+		},
+		{
+			name:   "older schema",
+			model:  map[string]float64{"2021-03-01": 1.08},
+			saved:  map[string]float64{"2021-03-01": 1.08},
+			change: func(saved *TipRankDividendModel) { saved.Schema = "1" },
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := newTestTipRankDividendModel(t, tt.model)
+
+			var saved *TipRankDividendModel
+			if tt.saved != nil {
+				saved = newTestTipRankDividendModel(t, tt.saved)
+			}
+
+			if tt.change != nil {
+				tt.change(saved)
+			}
+
+			if got := model.HasChanges(saved); got != tt.want {
+				t.Errorf("HasChanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTipRankDividendModelGetUpdate(t *testing.T) {
+	tests := []struct {
+		name       string
+		change     func(model *TipRankDividendModel)
+		wantFields []string
+	}{
+		{
+			name: "dividends are set on their own keys in date order",
+			wantFields: []string{
+				"modifiedAt", "enabled", "deleted", "schema", "ticker", "exchange", "symbol", "name", "currency", "amount",
+				"dividendHistory.1606780800", "dividendHistory.1614556800",
+			},
+		},
+		{
+			name: "empty fields are left as saved",
+			change: func(model *TipRankDividendModel) {
+				model.Name = ""
+				model.Amount = 0
+				model.LastRunID = "run"
+				model.Yield = 3.98
+			},
+			wantFields: []string{
+				"modifiedAt", "enabled", "deleted", "schema", "lastRunId", "ticker", "exchange", "symbol", "currency", "yield",
+				"dividendHistory.1606780800", "dividendHistory.1614556800",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := newTestTipRankDividendModel(t, map[string]float64{"2021-03-01": 1.08, "2020-12-01": 1.08})
+			if tt.change != nil {
+				tt.change(model)
+			}
+
+			update := model.GetUpdate()
+
+			var operators []string
+			for _, e := range update {
+				operators = append(operators, e.Key)
+			}
+
+			if want := []string{"$set", "$inc", "$setOnInsert"}; !reflect.DeepEqual(operators, want) {
+				t.Fatalf("update operators = %v, want %v", operators, want)
+			}
+
+			var fields []string
+			for _, e := range update[0].Value.(bson.D) {
+				fields = append(fields, e.Key)
+			}
+
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("$set fields = %v, want %v", fields, tt.wantFields)
+			}
+
+			if inc := update[1].Value.(bson.D); len(inc) != 1 || inc[0].Key != "version" || inc[0].Value != 1 {
+				t.Errorf("$inc = %v, want version incremented by 1", inc)
+			}
+
+			if setOnInsert := update[2].Value.(bson.D); len(setOnInsert) != 1 || setOnInsert[0].Key != "createdAt" {
+				t.Errorf("$setOnInsert = %v, want createdAt", setOnInsert)
+			}
+		})
+	}
+}
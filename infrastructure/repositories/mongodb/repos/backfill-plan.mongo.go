@@ -0,0 +1,306 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	logger "github.com/lenoobz/aws-lambda-logger"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/config"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/consts"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/repositories/mongodb/models"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/runid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BackfillPlanMongo struct
+type BackfillPlanMongo struct {
+	db     *mongo.Database
+	client *mongo.Client
+	log    logger.ContextLog
+	conf   *config.MongoConfig
+}
+
+// NewBackfillPlanMongo creates new backfill plan mongo repo
+func NewBackfillPlanMongo(db *mongo.Database, log logger.ContextLog, conf *config.MongoConfig) (*BackfillPlanMongo, error) {
+	if db != nil {
+		return &BackfillPlanMongo{
+			db:   db,
+			log:  log,
+			conf: conf,
+		}, nil
+	}
+
+	client, err := connectMongo(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BackfillPlanMongo{
+		db:     client.Database(conf.Dbname),
+		client: client,
+		log:    log,
+		conf:   conf,
+	}, nil
+}
+
+// Close disconnect from database
+func (r *BackfillPlanMongo) Close() {
+	ctx := context.Background()
+	r.log.Info(ctx, "close mongo client")
+
+	if r.client == nil {
+		return
+	}
+
+	if err := r.client.Disconnect(ctx); err != nil {
+		r.log.Error(ctx, "disconnect mongo failed", "error", err)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// Implement interface
+///////////////////////////////////////////////////////////////////////////////
+
+// FindBackfillPlan finds backfill plan of a given id
+func (r *BackfillPlanMongo) FindBackfillPlan(ctx context.Context, id string) (*entities.BackfillPlan, error) {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	col, err := r.getCollection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		r.log.Error(ctx, "invalid backfill plan id", "error", err, "id", id)
+		return nil, err
+	}
+
+	filter := bson.D{{
+		Key:   "_id",
+		Value: objectID,
+	}}
+
+	var backfillPlanModel models.BackfillPlanModel
+	if err := col.FindOne(ctx, filter).Decode(&backfillPlanModel); err != nil {
+		// ErrNoDocuments means that the filter did not match any documents in the collection
+		if err == mongo.ErrNoDocuments {
+			r.log.Info(ctx, "backfill plan not found", "id", id)
+			return nil, nil
+		}
+
+		r.log.Error(ctx, "decode find one failed", "error", err, "id", id)
+		return nil, err
+	}
+
+	return backfillPlanModel.ToEntity(), nil
+}
+
+// FindBackfillPlans finds all backfill plans newest first, their tasks only have a status to be counted
+func (r *BackfillPlanMongo) FindBackfillPlans(ctx context.Context) ([]*entities.BackfillPlan, error) {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	col, err := r.getCollection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	findOptions.SetProjection(bson.D{
+		{Key: "tasks.country", Value: 0},
+		{Key: "tasks.date", Value: 0},
+		{Key: "tasks.error", Value: 0},
+		{Key: "tasks.attempts", Value: 0},
+	})
+
+	cur, err := col.Find(ctx, bson.D{}, findOptions)
+	if err != nil {
+		r.log.Error(ctx, "find backfill plans failed", "error", err)
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var plans []*entities.BackfillPlan
+	for cur.Next(ctx) {
+		var backfillPlanModel models.BackfillPlanModel
+		if err := cur.Decode(&backfillPlanModel); err != nil {
+			r.log.Error(ctx, "decode backfill plan failed", "error", err)
+			return nil, err
+		}
+
+		plans = append(plans, backfillPlanModel.ToEntity())
+	}
+
+	if err := cur.Err(); err != nil {
+		r.log.Error(ctx, "iterate backfill plans failed", "error", err)
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+// InsertBackfillPlan inserts new backfill plan
+func (r *BackfillPlanMongo) InsertBackfillPlan(ctx context.Context, plan *entities.BackfillPlan) (string, error) {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	col, err := r.getCollection(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	backfillPlanModel := models.NewBackfillPlanModel(plan, runid.FromContext(ctx), r.conf.SchemaVersion)
+
+	res, err := col.InsertOne(ctx, backfillPlanModel)
+	if err != nil {
+		r.log.Error(ctx, "insert one failed", "error", err)
+		return "", err
+	}
+
+	objectID, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		r.log.Error(ctx, "invalid inserted id", "insertedId", res.InsertedID)
+		return "", fmt.Errorf("invalid inserted id")
+	}
+
+	return objectID.Hex(), nil
+}
+
+// UpdateBackfillPlanStatus updates status of a backfill plan
+func (r *BackfillPlanMongo) UpdateBackfillPlanStatus(ctx context.Context, id string, status entities.BackfillStatus) error {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	col, err := r.getCollection(ctx)
+	if err != nil {
+		return err
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		r.log.Error(ctx, "invalid backfill plan id", "error", err, "id", id)
+		return err
+	}
+
+	filter := bson.D{{
+		Key:   "_id",
+		Value: objectID,
+	}}
+
+	update := bson.D{{
+		Key: "$set",
+		Value: bson.D{
+			{Key: "status", Value: string(status)},
+			{Key: "modifiedAt", Value: time.Now().UTC().Unix()},
+			{Key: "lastRunId", Value: runid.FromContext(ctx)},
+		},
+	}}
+
+	res, err := col.UpdateOne(ctx, filter, update)
+	if err != nil {
+		r.log.Error(ctx, "update one failed", "error", err, "id", id)
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		r.log.Error(ctx, "backfill plan not found", "id", id)
+		return fmt.Errorf("backfill plan %s not found", id)
+	}
+
+	return nil
+}
+
+// UpdateBackfillTask updates status of a task of a backfill plan
+func (r *BackfillPlanMongo) UpdateBackfillTask(ctx context.Context, id string, task *entities.BackfillTask) error {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	col, err := r.getCollection(ctx)
+	if err != nil {
+		return err
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		r.log.Error(ctx, "invalid backfill plan id", "error", err, "id", id)
+		return err
+	}
+
+	filter := bson.D{
+		{
+			Key:   "_id",
+			Value: objectID,
+		},
+		{
+			Key: "tasks",
+			Value: bson.D{{
+				Key: "$elemMatch",
+				Value: bson.D{
+					{Key: "country", Value: task.Country},
+					{Key: "date", Value: task.Date},
+				},
+			}},
+		},
+	}
+
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.D{
+				{Key: "tasks.$.status", Value: string(task.Status)},
+				{Key: "tasks.$.error", Value: task.Error},
+				{Key: "modifiedAt", Value: time.Now().UTC().Unix()},
+				{Key: "lastRunId", Value: runid.FromContext(ctx)},
+			},
+		},
+		{
+			Key: "$inc",
+			Value: bson.D{{
+				Key:   "tasks.$.attempts",
+				Value: 1,
+			}},
+		},
+	}
+
+	res, err := col.UpdateOne(ctx, filter, update)
+	if err != nil {
+		r.log.Error(ctx, "update one failed", "error", err, "id", id, "country", task.Country, "date", task.Date)
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		r.log.Error(ctx, "backfill task not found", "id", id, "country", task.Country, "date", task.Date)
+		return fmt.Errorf("backfill task %s %s of plan %s not found", task.Country, task.Date, id)
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////
+// Implement helper function
+///////////////////////////////////////////////////////////
+
+// getCollection gets backfill plan collection
+func (r *BackfillPlanMongo) getCollection(ctx context.Context) (*mongo.Collection, error) {
+	// what collection we are going to use
+	colname, ok := r.conf.Colnames[consts.TIPRANK_BACKFILL_PLAN_COLLECTION]
+	if !ok {
+		r.log.Error(ctx, "cannot find collection name")
+		return nil, fmt.Errorf("cannot find collection name")
+	}
+
+	return r.db.Collection(colname), nil
+}
@@ -0,0 +1,215 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/consts"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/repositories/mongodb/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// insertDividendEvents upserts every dividend event of stocks to write into the dividend event collection
+// with a single bulk write, the stock document stays the source of truth of its dividend history
+func (r *TipRankDividendMongo) insertDividendEvents(ctx context.Context, tiprankDividendModels []*models.TipRankDividendModel) error {
+	writeModels := getDividendEventWriteModels(tiprankDividendModels, r.conf.SchemaVersion)
+	if len(writeModels) == 0 {
+		return nil
+	}
+
+	// what collection we are going to use
+	colname, ok := r.conf.Colnames[consts.TIPRANK_DIVIDEND_EVENT_COLLECTION]
+	if !ok {
+		r.log.Error(ctx, "cannot find collection name")
+		return fmt.Errorf("cannot find collection name")
+	}
+	col := r.db.Collection(colname)
+
+	// writes of different events do not depend on each other
+	opts := options.BulkWrite().SetOrdered(false)
+
+	if _, err := col.BulkWrite(ctx, writeModels, opts); err != nil {
+		r.log.Error(ctx, "bulk write dividend events failed", "error", err, "count", len(writeModels))
+		return err
+	}
+
+	return nil
+}
+
+// listDividendEventsByDateRange gets TipRank dividends with a dividend date field between from and to dates
+// from the indexed dividend event collection. Events are grouped by symbol into the saved stocks, read
+// without their dividend history, so that each stock only has the dividends of the range
+func (r *TipRankDividendMongo) listDividendEventsByDateRange(ctx context.Context, field string, from time.Time, to time.Time, exchanges []string) ([]*entities.DividendStock, error) {
+	// what collection we are going to use
+	colname, ok := r.conf.Colnames[consts.TIPRANK_DIVIDEND_EVENT_COLLECTION]
+	if !ok {
+		r.log.Error(ctx, "cannot find collection name")
+		return nil, fmt.Errorf("cannot find collection name")
+	}
+	col := r.db.Collection(colname)
+
+	filter := bson.D{{
+		Key: field,
+		Value: bson.D{
+			{Key: "$gte", Value: from},
+			{Key: "$lte", Value: to},
+		},
+	}}
+
+	if len(exchanges) > 0 {
+		filter = append(filter, bson.E{
+			Key:   "exchange",
+			Value: bson.D{{Key: "$in", Value: exchanges}},
+		})
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "exchange", Value: 1}, {Key: "symbol", Value: 1}, {Key: "exDividendDate", Value: 1}})
+
+	cur, err := col.Find(ctx, filter, findOptions)
+	if err != nil {
+		r.log.Error(ctx, "find dividend events failed", "error", err, "field", field, "from", from, "to", to)
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var symbols []*entities.Symbol
+	dividendEventsBySymbol := map[string][]*models.DividendEventModel{}
+	for cur.Next(ctx) {
+		var dividendEventModel models.DividendEventModel
+		if err := cur.Decode(&dividendEventModel); err != nil {
+			r.log.Error(ctx, "decode dividend event failed", "error", err)
+			return nil, err
+		}
+
+		symbol := &entities.Symbol{
+			Exchange: dividendEventModel.Exchange,
+			Symbol:   dividendEventModel.Symbol,
+		}
+
+		if _, found := dividendEventsBySymbol[symbol.String()]; !found {
+			symbols = append(symbols, symbol)
+		}
+		dividendEventsBySymbol[symbol.String()] = append(dividendEventsBySymbol[symbol.String()], &dividendEventModel)
+	}
+
+	if err := cur.Err(); err != nil {
+		r.log.Error(ctx, "iterate dividend events failed", "error", err)
+		return nil, err
+	}
+
+	if len(symbols) == 0 {
+		return toDividendStocks(nil), nil
+	}
+
+	savedTipRankDividends, err := r.findTipRankDividendsWithoutHistory(ctx, symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	var tiprankDividendModels []*models.TipRankDividendModel
+	for _, symbol := range symbols {
+		dividendEventModels := dividendEventsBySymbol[symbol.String()]
+
+		// an event of a stock which is not saved keeps the stock fields it was written with
+		tiprankDividendModel, found := savedTipRankDividends[symbol.String()]
+		if !found {
+			first := dividendEventModels[0]
+			tiprankDividendModel = &models.TipRankDividendModel{
+				Ticker:   first.Ticker,
+				Exchange: first.Exchange,
+				Symbol:   first.Symbol,
+				Name:     first.Name,
+				Currency: first.Currency,
+			}
+		}
+
+		tiprankDividendModel.DividendHistory = map[int64]*models.DividendHistoryModel{}
+		for _, dividendEventModel := range dividendEventModels {
+			tiprankDividendModel.DividendHistory[dividendEventModel.ExDividendDate.Unix()] = dividendEventModel.ToDividendHistoryModel()
+		}
+
+		tiprankDividendModels = append(tiprankDividendModels, tiprankDividendModel)
+	}
+
+	return toDividendStocks(tiprankDividendModels), nil
+}
+
+// findTipRankDividendsWithoutHistory finds TipRank dividends of given symbols keyed on symbol, without their dividend history
+func (r *TipRankDividendMongo) findTipRankDividendsWithoutHistory(ctx context.Context, symbols []*entities.Symbol) (map[string]*models.TipRankDividendModel, error) {
+	// what collection we are going to use
+	colname, ok := r.conf.Colnames[consts.TIPRANK_DIVIDEND_LIST_COLLECTION]
+	if !ok {
+		r.log.Error(ctx, "cannot find collection name")
+		return nil, fmt.Errorf("cannot find collection name")
+	}
+	col := r.db.Collection(colname)
+
+	var symbolFilters bson.A
+	for _, symbol := range symbols {
+		symbolFilters = append(symbolFilters, bson.D{
+			{
+				Key:   "exchange",
+				Value: symbol.Exchange,
+			},
+			{
+				Key:   "symbol",
+				Value: symbol.Symbol,
+			},
+		})
+	}
+
+	filter := bson.D{{
+		Key:   "$or",
+		Value: symbolFilters,
+	}}
+
+	findOptions := options.Find()
+	findOptions.SetProjection(bson.D{{Key: "dividendHistory", Value: 0}})
+
+	cur, err := col.Find(ctx, filter, findOptions)
+	if err != nil {
+		r.log.Error(ctx, "find TipRank dividends by symbols failed", "error", err, "count", len(symbols))
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	tiprankDividendModels, err := r.decodeTipRankDividends(ctx, cur)
+	if err != nil {
+		return nil, err
+	}
+
+	// a symbol has a single document, legacy documents have no symbol
+	savedTipRankDividends := map[string]*models.TipRankDividendModel{}
+	for _, tiprankDividendModel := range tiprankDividendModels {
+		symbol := entities.Symbol{
+			Exchange: tiprankDividendModel.Exchange,
+			Symbol:   tiprankDividendModel.Symbol,
+		}
+
+		savedTipRankDividends[symbol.String()] = tiprankDividendModel
+	}
+
+	return savedTipRankDividends, nil
+}
+
+// getDividendEventWriteModels gets upserts of every dividend event of given stocks
+func getDividendEventWriteModels(tiprankDividendModels []*models.TipRankDividendModel, schemaVersion string) []mongo.WriteModel {
+	var writeModels []mongo.WriteModel
+	for _, tiprankDividendModel := range tiprankDividendModels {
+		for _, dividendEventModel := range models.NewDividendEventModels(tiprankDividendModel, schemaVersion) {
+			writeModel := mongo.NewUpdateOneModel().
+				SetFilter(dividendEventModel.GetFilter()).
+				SetUpdate(dividendEventModel.GetUpdate()).
+				SetUpsert(true)
+
+			writeModels = append(writeModels, writeModel)
+		}
+	}
+
+	return writeModels
+}
@@ -0,0 +1,260 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/consts"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// namespaceNotFoundCode is the error code of listing indexes of a collection which does not exist yet
+const namespaceNotFoundCode = 26
+
+// indexSpec is an index declared in code, key is the Colnames's key of its collection
+type indexSpec struct {
+	key           string
+	name          string
+	keys          bson.D
+	unique        bool
+	partialFilter bson.D
+}
+
+// savedIndex is an index as listed by the database
+type savedIndex struct {
+	Name                    string `bson:"name"`
+	Key                     bson.D `bson:"key"`
+	Unique                  bool   `bson:"unique,omitempty"`
+	PartialFilterExpression bson.D `bson:"partialFilterExpression,omitempty"`
+}
+
+// indexSpecs lists indexes of every collection. Dividend dates are keys of the dividend history map
+// so they cannot be indexed there, date range queries use the indexed dividend events instead
+var indexSpecs = []indexSpec{
+	{
+		key:  consts.TIPRANK_DIVIDEND_LIST_COLLECTION,
+		name: "ticker_1__id_1",
+		keys: bson.D{{Key: "ticker", Value: 1}, {Key: "_id", Value: 1}},
+	},
+	{
+		key:    consts.TIPRANK_DIVIDEND_LIST_COLLECTION,
+		name:   "exchange_1_symbol_1",
+		keys:   bson.D{{Key: "exchange", Value: 1}, {Key: "symbol", Value: 1}},
+		unique: true,
+		// legacy dividends have no symbol until they are migrated
+		partialFilter: bson.D{{Key: "exchange", Value: bson.D{{Key: "$exists", Value: true}}}},
+	},
+	{
+		key:    consts.TIPRANK_DIVIDEND_EVENT_COLLECTION,
+		name:   "exchange_1_symbol_1_exDividendDate_1",
+		keys:   bson.D{{Key: "exchange", Value: 1}, {Key: "symbol", Value: 1}, {Key: "exDividendDate", Value: 1}},
+		unique: true,
+	},
+	{
+		key:  consts.TIPRANK_DIVIDEND_EVENT_COLLECTION,
+		name: "exDividendDate_1",
+		keys: bson.D{{Key: "exDividendDate", Value: 1}},
+	},
+	{
+		key:  consts.TIPRANK_DIVIDEND_EVENT_COLLECTION,
+		name: "payoutDate_1",
+		keys: bson.D{{Key: "payoutDate", Value: 1}},
+	},
+	{
+		key:    consts.TIPRANK_RESPONSE_HASH_COLLECTION,
+		name:   "country_1_date_1",
+		keys:   bson.D{{Key: "country", Value: 1}, {Key: "date", Value: 1}},
+		unique: true,
+	},
+	{
+		key:    consts.TIPRANK_RESPONSE_QUARANTINE_COLLECTION,
+		name:   "country_1_date_1_ticker_1_hash_1",
+		keys:   bson.D{{Key: "country", Value: 1}, {Key: "date", Value: 1}, {Key: "ticker", Value: 1}, {Key: "hash", Value: 1}},
+		unique: true,
+		// responses quarantined before they had a hash may be duplicated
+		partialFilter: bson.D{{Key: "hash", Value: bson.D{{Key: "$exists", Value: true}}}},
+	},
+	{
+		key:  consts.TIPRANK_DIVIDEND_REJECT_COLLECTION,
+		name: "country_1_ticker_1",
+		keys: bson.D{{Key: "country", Value: 1}, {Key: "ticker", Value: 1}},
+	},
+	{
+		key:  consts.TIPRANK_DIVIDEND_REJECT_COLLECTION,
+		name: "runId_1",
+		keys: bson.D{{Key: "runId", Value: 1}},
+	},
+	{
+		key:  consts.TIPRANK_BACKFILL_PLAN_COLLECTION,
+		name: "createdAt_-1",
+		keys: bson.D{{Key: "createdAt", Value: -1}},
+	},
+	{
+		key:    consts.TIPRANK_MIGRATION_COLLECTION,
+		name:   "version_1",
+		keys:   bson.D{{Key: "version", Value: 1}},
+		unique: true,
+	},
+}
+
+// getIndexedCollections gets Colnames's keys of collections having declared indexes, in declaration order
+func getIndexedCollections() []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, spec := range indexSpecs {
+		if !seen[spec.key] {
+			seen[spec.key] = true
+			keys = append(keys, spec.key)
+		}
+	}
+
+	return keys
+}
+
+// toIndexModel converts the index spec to mongo index model
+func (s *indexSpec) toIndexModel() mongo.IndexModel {
+	indexOptions := options.Index().SetName(s.name)
+
+	if s.unique {
+		indexOptions.SetUnique(true)
+	}
+
+	if len(s.partialFilter) > 0 {
+		indexOptions.SetPartialFilterExpression(s.partialFilter)
+	}
+
+	return mongo.IndexModel{
+		Keys:    s.keys,
+		Options: indexOptions,
+	}
+}
+
+// matches checks whether a saved index is the index spec, key values are compared
+// as printed since the database gives them back as int32 or float64
+func (s *indexSpec) matches(saved *savedIndex) bool {
+	if s.unique != saved.Unique || fmt.Sprint(s.partialFilter) != fmt.Sprint(saved.PartialFilterExpression) {
+		return false
+	}
+
+	if len(s.keys) != len(saved.Key) {
+		return false
+	}
+
+	for i, k := range s.keys {
+		if k.Key != saved.Key[i].Key || fmt.Sprint(k.Value) != fmt.Sprint(saved.Key[i].Value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// reconcileCollectionIndexes reconciles declared indexes of a collection
+func (r *MigrationMongo) reconcileCollectionIndexes(ctx context.Context, key string, dryRun bool) ([]*entities.IndexChange, error) {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	col, err := r.getCollectionByKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	savedIndexes, err := r.listIndexes(ctx, col)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []*entities.IndexChange
+	declared := map[string]bool{"_id_": true}
+
+	for i := range indexSpecs {
+		spec := &indexSpecs[i]
+		if spec.key != key {
+			continue
+		}
+		declared[spec.name] = true
+
+		change := &entities.IndexChange{
+			Collection: col.Name(),
+			Index:      spec.name,
+			Action:     entities.IndexActionCreate,
+		}
+
+		if saved, found := savedIndexes[spec.name]; found {
+			change.Action = entities.IndexActionRecreate
+			if spec.matches(saved) {
+				change.Action = entities.IndexActionUnchanged
+			}
+		}
+
+		changes = append(changes, change)
+
+		if dryRun || change.Action == entities.IndexActionUnchanged {
+			continue
+		}
+
+		if change.Action == entities.IndexActionRecreate {
+			r.log.Info(ctx, "drop changed index", "collection", col.Name(), "index", spec.name)
+			if _, err := col.Indexes().DropOne(ctx, spec.name); err != nil {
+				r.log.Error(ctx, "drop index failed", "error", err, "collection", col.Name(), "index", spec.name)
+				return changes, err
+			}
+		}
+
+		r.log.Info(ctx, "create index", "collection", col.Name(), "index", spec.name)
+		if _, err := col.Indexes().CreateOne(ctx, spec.toIndexModel()); err != nil {
+			r.log.Error(ctx, "create index failed", "error", err, "collection", col.Name(), "index", spec.name)
+			return changes, err
+		}
+	}
+
+	for name := range savedIndexes {
+		if !declared[name] {
+			r.log.Warn(ctx, "index is not declared", "collection", col.Name(), "index", name)
+			changes = append(changes, &entities.IndexChange{
+				Collection: col.Name(),
+				Index:      name,
+				Action:     entities.IndexActionExtra,
+			})
+		}
+	}
+
+	return changes, nil
+}
+
+// listIndexes lists saved indexes of a collection keyed by name, a collection which does not exist yet has none
+func (r *MigrationMongo) listIndexes(ctx context.Context, col *mongo.Collection) (map[string]*savedIndex, error) {
+	savedIndexes := map[string]*savedIndex{}
+
+	cur, err := col.Indexes().List(ctx)
+	if err != nil {
+		if cmdErr, ok := err.(mongo.CommandError); ok && cmdErr.Code == namespaceNotFoundCode {
+			return savedIndexes, nil
+		}
+
+		r.log.Error(ctx, "list indexes failed", "error", err, "collection", col.Name())
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var index savedIndex
+		if err := cur.Decode(&index); err != nil {
+			r.log.Error(ctx, "decode index failed", "error", err, "collection", col.Name())
+			return nil, err
+		}
+
+		savedIndexes[index.Name] = &index
+	}
+
+	if err := cur.Err(); err != nil {
+		r.log.Error(ctx, "iterate indexes failed", "error", err, "collection", col.Name())
+		return nil, err
+	}
+
+	return savedIndexes, nil
+}
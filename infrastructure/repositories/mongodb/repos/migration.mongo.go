@@ -0,0 +1,213 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	logger "github.com/lenoobz/aws-lambda-logger"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/config"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/consts"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/repositories/mongodb/models"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/runid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MigrationMongo struct
+type MigrationMongo struct {
+	db     *mongo.Database
+	client *mongo.Client
+	log    logger.ContextLog
+	conf   *config.MongoConfig
+}
+
+// NewMigrationMongo creates new migration mongo repo
+func NewMigrationMongo(db *mongo.Database, log logger.ContextLog, conf *config.MongoConfig) (*MigrationMongo, error) {
+	if db != nil {
+		return &MigrationMongo{
+			db:   db,
+			log:  log,
+			conf: conf,
+		}, nil
+	}
+
+	client, err := connectMongo(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MigrationMongo{
+		db:     client.Database(conf.Dbname),
+		client: client,
+		log:    log,
+		conf:   conf,
+	}, nil
+}
+
+// Close disconnect from database
+func (r *MigrationMongo) Close() {
+	ctx := context.Background()
+	r.log.Info(ctx, "close mongo client")
+
+	if r.client == nil {
+		return
+	}
+
+	if err := r.client.Disconnect(ctx); err != nil {
+		r.log.Error(ctx, "disconnect mongo failed", "error", err)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// Implement interface
+///////////////////////////////////////////////////////////////////////////////
+
+// GetSchemaVersion gets the configured schema version, migrations up to this version are run
+func (r *MigrationMongo) GetSchemaVersion() (int, error) {
+	schemaVersion, err := strconv.Atoi(r.conf.SchemaVersion)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version %q: %v", r.conf.SchemaVersion, err)
+	}
+
+	return schemaVersion, nil
+}
+
+// GetMigrations gets migrations up to the configured schema version, in order
+func (r *MigrationMongo) GetMigrations() ([]*entities.Migration, error) {
+	schemaVersion, err := r.GetSchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	var declared []*entities.Migration
+	for _, m := range migrations {
+		if m.version > schemaVersion {
+			break
+		}
+
+		declared = append(declared, &entities.Migration{
+			Version: m.version,
+			Name:    m.name,
+		})
+	}
+
+	return declared, nil
+}
+
+// FindAppliedMigrations finds applied migrations keyed by version
+func (r *MigrationMongo) FindAppliedMigrations(ctx context.Context) (map[int]*entities.Migration, error) {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	col, err := r.getCollection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cur, err := col.Find(ctx, bson.D{})
+	if err != nil {
+		r.log.Error(ctx, "find applied migrations failed", "error", err)
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	applied := map[int]*entities.Migration{}
+	for cur.Next(ctx) {
+		var migrationModel models.MigrationModel
+		if err := cur.Decode(&migrationModel); err != nil {
+			r.log.Error(ctx, "decode migration failed", "error", err)
+			return nil, err
+		}
+
+		applied[migrationModel.Version] = migrationModel.ToEntity()
+	}
+
+	if err := cur.Err(); err != nil {
+		r.log.Error(ctx, "iterate migrations failed", "error", err)
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+// RunMigration runs the migration of a given version and gets the number of changed documents,
+// a dry run gets the number of documents it would change without changing them
+func (r *MigrationMongo) RunMigration(ctx context.Context, version int, dryRun bool) (int64, error) {
+	for _, m := range migrations {
+		if m.version == version {
+			r.log.Info(ctx, "running migration", "version", m.version, "name", m.name, "dryRun", dryRun)
+			return m.run(r, ctx, dryRun)
+		}
+	}
+
+	r.log.Error(ctx, "unknown migration", "version", version)
+	return 0, fmt.Errorf("unknown migration %d", version)
+}
+
+// InsertMigration records an applied migration, recording it again replaces the previous record
+func (r *MigrationMongo) InsertMigration(ctx context.Context, migration *entities.Migration) error {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	col, err := r.getCollection(ctx)
+	if err != nil {
+		return err
+	}
+
+	migrationModel := models.NewMigrationModel(migration, runid.FromContext(ctx))
+
+	filter := bson.D{{
+		Key:   "version",
+		Value: migrationModel.Version,
+	}}
+
+	if _, err := col.ReplaceOne(ctx, filter, migrationModel, options.Replace().SetUpsert(true)); err != nil {
+		r.log.Error(ctx, "replace one failed", "error", err, "version", migrationModel.Version)
+		return err
+	}
+
+	return nil
+}
+
+// ReconcileIndexes creates missing indexes and recreates changed ones, indexes not declared in code are reported but kept.
+// A dry run reports the changes without making them
+func (r *MigrationMongo) ReconcileIndexes(ctx context.Context, dryRun bool) ([]*entities.IndexChange, error) {
+	var changes []*entities.IndexChange
+
+	for _, colname := range getIndexedCollections() {
+		colChanges, err := r.reconcileCollectionIndexes(ctx, colname, dryRun)
+		if err != nil {
+			return changes, err
+		}
+
+		changes = append(changes, colChanges...)
+	}
+
+	return changes, nil
+}
+
+///////////////////////////////////////////////////////////
+// Implement helper function
+///////////////////////////////////////////////////////////
+
+// getCollection gets migration collection
+func (r *MigrationMongo) getCollection(ctx context.Context) (*mongo.Collection, error) {
+	return r.getCollectionByKey(ctx, consts.TIPRANK_MIGRATION_COLLECTION)
+}
+
+// getCollectionByKey gets collection of a given Colnames's key
+func (r *MigrationMongo) getCollectionByKey(ctx context.Context, key string) (*mongo.Collection, error) {
+	// what collection we are going to use
+	colname, ok := r.conf.Colnames[key]
+	if !ok {
+		r.log.Error(ctx, "cannot find collection name", "key", key)
+		return nil, fmt.Errorf("cannot find collection name of %s", key)
+	}
+
+	return r.db.Collection(colname), nil
+}
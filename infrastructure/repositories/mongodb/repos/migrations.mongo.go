@@ -0,0 +1,259 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/config"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/consts"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/repositories/mongodb/models"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/symbology"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migration is a change of saved documents which brings them to a schema version. Running a migration
+// again changes nothing, a dry run gets the number of documents it would change
+type migration struct {
+	version int
+	name    string
+	run     func(r *MigrationMongo, ctx context.Context, dryRun bool) (int64, error)
+}
+
+// migrations lists migrations ordered by version, schema version 1 is the baseline and has none.
+// Bump SchemaVersion of the mongo config when adding one
+var migrations = []migration{
+	{
+		version: 2,
+		name:    "add exchange and symbol to legacy dividends",
+		run:     (*MigrationMongo).migrateSymbols,
+	},
+	{
+		version: 3,
+		name:    "add version to unversioned dividends",
+		run:     (*MigrationMongo).migrateVersions,
+	},
+	{
+		version: 4,
+		name:    "copy dividend history into dividend events",
+		run:     (*MigrationMongo).migrateDividendEvents,
+	},
+}
+
+// migrationBatchSize is how many writes a migration sends in one bulk write
+const migrationBatchSize = 500
+
+// migrateSymbols adds canonical exchange and symbol to dividends written before symbols were normalized.
+// A dividend whose symbol is already saved by another document is left as is, it needs a manual merge.
+// Migrations scan whole collections so they are not bound by the query timeout
+func (r *MigrationMongo) migrateSymbols(ctx context.Context, dryRun bool) (int64, error) {
+	col, err := r.getCollectionByKey(ctx, consts.TIPRANK_DIVIDEND_LIST_COLLECTION)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.D{{
+		Key: "exchange",
+		Value: bson.D{{
+			Key:   "$exists",
+			Value: false,
+		}},
+	}}
+
+	cur, err := col.Find(ctx, filter)
+	if err != nil {
+		r.log.Error(ctx, "find legacy dividends failed", "error", err)
+		return 0, err
+	}
+	defer cur.Close(ctx)
+
+	var affected int64
+	for cur.Next(ctx) {
+		var tiprankDividendModel models.TipRankDividendModel
+		if err := cur.Decode(&tiprankDividendModel); err != nil {
+			r.log.Error(ctx, "decode legacy dividend failed", "error", err)
+			return affected, err
+		}
+
+		symbol, err := getLegacySymbol(&tiprankDividendModel)
+		if err != nil {
+			r.log.Warn(ctx, "skip legacy dividend without symbol", "ticker", tiprankDividendModel.Ticker, "currency", tiprankDividendModel.Currency, "error", err)
+			continue
+		}
+
+		symbolFilter := bson.D{
+			{Key: "exchange", Value: symbol.Exchange},
+			{Key: "symbol", Value: symbol.Symbol},
+		}
+
+		saved, err := col.CountDocuments(ctx, symbolFilter)
+		if err != nil {
+			r.log.Error(ctx, "count dividends of symbol failed", "error", err, "exchange", symbol.Exchange, "symbol", symbol.Symbol)
+			return affected, err
+		}
+
+		if saved > 0 {
+			r.log.Warn(ctx, "skip legacy dividend of saved symbol", "ticker", tiprankDividendModel.Ticker, "exchange", symbol.Exchange, "symbol", symbol.Symbol)
+			continue
+		}
+
+		affected++
+		if dryRun {
+			continue
+		}
+
+		idFilter := append(bson.D{{Key: "_id", Value: tiprankDividendModel.ID}}, filter...)
+
+		update := bson.D{{
+			Key: "$set",
+			Value: bson.D{
+				{Key: "exchange", Value: symbol.Exchange},
+				{Key: "symbol", Value: symbol.Symbol},
+				{Key: "schema", Value: "2"},
+			},
+		}}
+
+		if _, err := col.UpdateOne(ctx, idFilter, update); err != nil {
+			r.log.Error(ctx, "update legacy dividend failed", "error", err, "ticker", tiprankDividendModel.Ticker)
+			return affected, err
+		}
+	}
+
+	if err := cur.Err(); err != nil {
+		r.log.Error(ctx, "iterate legacy dividends failed", "error", err)
+		return affected, err
+	}
+
+	return affected, nil
+}
+
+// migrateVersions sets version 1 on dividends written before documents were versioned,
+// so that every compare-and-swap write filters on a saved version
+func (r *MigrationMongo) migrateVersions(ctx context.Context, dryRun bool) (int64, error) {
+	col, err := r.getCollectionByKey(ctx, consts.TIPRANK_DIVIDEND_LIST_COLLECTION)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.D{{
+		Key: "version",
+		Value: bson.D{{
+			Key:   "$exists",
+			Value: false,
+		}},
+	}}
+
+	if dryRun {
+		count, err := col.CountDocuments(ctx, filter)
+		if err != nil {
+			r.log.Error(ctx, "count unversioned dividends failed", "error", err)
+			return 0, err
+		}
+
+		return count, nil
+	}
+
+	update := bson.D{{
+		Key: "$set",
+		Value: bson.D{
+			{Key: "version", Value: 1},
+			{Key: "schema", Value: "3"},
+		},
+	}}
+
+	res, err := col.UpdateMany(ctx, filter, update)
+	if err != nil {
+		r.log.Error(ctx, "update unversioned dividends failed", "error", err)
+		return 0, err
+	}
+
+	return res.ModifiedCount, nil
+}
+
+// migrateDividendEvents upserts every event of the dividend history of every stock into the dividend event collection,
+// events already copied are written again with the same content
+func (r *MigrationMongo) migrateDividendEvents(ctx context.Context, dryRun bool) (int64, error) {
+	col, err := r.getCollectionByKey(ctx, consts.TIPRANK_DIVIDEND_LIST_COLLECTION)
+	if err != nil {
+		return 0, err
+	}
+
+	eventCol, err := r.getCollectionByKey(ctx, consts.TIPRANK_DIVIDEND_EVENT_COLLECTION)
+	if err != nil {
+		return 0, err
+	}
+
+	cur, err := col.Find(ctx, bson.D{})
+	if err != nil {
+		r.log.Error(ctx, "find dividends failed", "error", err)
+		return 0, err
+	}
+	defer cur.Close(ctx)
+
+	var affected int64
+	var writeModels []mongo.WriteModel
+
+	// writeBatch upserts the pending events, a dry run counts them
+	writeBatch := func() error {
+		if len(writeModels) == 0 {
+			return nil
+		}
+
+		if dryRun {
+			affected += int64(len(writeModels))
+			writeModels = nil
+			return nil
+		}
+
+		res, err := eventCol.BulkWrite(ctx, writeModels, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			r.log.Error(ctx, "bulk write dividend events failed", "error", err, "count", len(writeModels))
+			return err
+		}
+
+		affected += res.ModifiedCount + res.UpsertedCount
+		writeModels = nil
+		return nil
+	}
+
+	for cur.Next(ctx) {
+		var tiprankDividendModel models.TipRankDividendModel
+		if err := cur.Decode(&tiprankDividendModel); err != nil {
+			r.log.Error(ctx, "decode dividend failed", "error", err)
+			return affected, err
+		}
+
+		writeModels = append(writeModels, getDividendEventWriteModels([]*models.TipRankDividendModel{&tiprankDividendModel}, "4")...)
+		if len(writeModels) < migrationBatchSize {
+			continue
+		}
+
+		if err := writeBatch(); err != nil {
+			return affected, err
+		}
+	}
+
+	if err := cur.Err(); err != nil {
+		r.log.Error(ctx, "iterate dividends failed", "error", err)
+		return affected, err
+	}
+
+	if err := writeBatch(); err != nil {
+		return affected, err
+	}
+
+	return affected, nil
+}
+
+// getLegacySymbol gets canonical symbol of a legacy dividend from its ticker and the market of its currency
+func getLegacySymbol(tiprankDividendModel *models.TipRankDividendModel) (*entities.Symbol, error) {
+	for _, market := range config.Markets {
+		if market.Currency == tiprankDividendModel.Currency {
+			return symbology.Normalize(tiprankDividendModel.Ticker, market.Code)
+		}
+	}
+
+	return nil, fmt.Errorf("no market of currency %q", tiprankDividendModel.Currency)
+}
@@ -0,0 +1,61 @@
+package repos
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// pageCursorVersion is bumped whenever the cursor payload changes
+const pageCursorVersion = 1
+
+// pageCursor is the position of the last stock of a page, stocks are sorted by ticker then id
+type pageCursor struct {
+	Version int    `json:"v"`
+	Ticker  string `json:"t"`
+	ID      string `json:"id"`
+}
+
+// encodePageCursor encodes the position of a stock into an opaque cursor
+func encodePageCursor(ticker string, id *primitive.ObjectID) (string, error) {
+	if id == nil {
+		return "", fmt.Errorf("stock %s has no id", ticker)
+	}
+
+	data, err := json.Marshal(pageCursor{
+		Version: pageCursorVersion,
+		Ticker:  ticker,
+		ID:      id.Hex(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodePageCursor decodes the position of a stock from a cursor
+func decodePageCursor(encodedCursor string) (string, primitive.ObjectID, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encodedCursor)
+	if err != nil {
+		return "", primitive.NilObjectID, fmt.Errorf("invalid page cursor: %v", err)
+	}
+
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return "", primitive.NilObjectID, fmt.Errorf("invalid page cursor: %v", err)
+	}
+
+	if cursor.Version != pageCursorVersion {
+		return "", primitive.NilObjectID, fmt.Errorf("unsupported page cursor version %d", cursor.Version)
+	}
+
+	id, err := primitive.ObjectIDFromHex(cursor.ID)
+	if err != nil {
+		return "", primitive.NilObjectID, fmt.Errorf("invalid page cursor: %v", err)
+	}
+
+	return cursor.Ticker, id, nil
+}
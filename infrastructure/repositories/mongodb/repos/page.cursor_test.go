@@ -0,0 +1,69 @@
+package repos
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		ticker string
+		id     string
+	}{
+		{name: "ticker with exchange prefix", ticker: "TSE:RY", id: "60a1b2c3d4e5f60718293a4b"},
+		{name: "ticker with dot", ticker: "BRK.B", id: "5f1e2d3c4b5a697887766554"},
+		{name: "empty ticker", ticker: "", id: "000000000000000000000001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := primitive.ObjectIDFromHex(tt.id)
+			if err != nil {
+				t.Fatalf("invalid test id: %v", err)
+			}
+
+			cursor, err := encodePageCursor(tt.ticker, &id)
+			if err != nil {
+				t.Fatalf("encodePageCursor() error = %v", err)
+			}
+
+			ticker, gotID, err := decodePageCursor(cursor)
+			if err != nil {
+				t.Fatalf("decodePageCursor() error = %v", err)
+			}
+
+			if ticker != tt.ticker || gotID != id {
+				t.Errorf("decodePageCursor() = %s, %s, want %s, %s", ticker, gotID.Hex(), tt.ticker, tt.id)
+			}
+		})
+	}
+}
+
+func TestEncodePageCursorWithoutID(t *testing.T) {
+	if _, err := encodePageCursor("TSE:RY", nil); err == nil {
+		t.Errorf("encodePageCursor() error = nil, want an error")
+	}
+}
+
+func TestDecodePageCursorInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "not base64", cursor: "not a cursor!"},
+		{name: "not json", cursor: base64.RawURLEncoding.EncodeToString([]byte("{"))},
+		{name: "unsupported version", cursor: base64.RawURLEncoding.EncodeToString([]byte(`{"v":2,"t":"RY","id":"60a1b2c3d4e5f60718293a4b"}`))},
+		{name: "invalid id", cursor: base64.RawURLEncoding.EncodeToString([]byte(`{"v":1,"t":"RY","id":"RY"}`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodePageCursor(tt.cursor); err == nil {
+				t.Errorf("decodePageCursor(%q) error = nil, want an error", tt.cursor)
+			}
+		})
+	}
+}
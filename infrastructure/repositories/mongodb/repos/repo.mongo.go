@@ -2,17 +2,57 @@ package repos
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"time"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/config"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// connectMongo creates mongo client by making new connection from the mongo config
+func connectMongo(conf *config.MongoConfig) (*mongo.Client, error) {
+	// set context with timeout from the config
+	// create new context for the query
+	ctx, cancel := createContext(context.Background(), conf.TimeoutMS)
+	defer cancel()
+
+	// set mongo client options
+	clientOptions := options.Client()
+
+	// set min pool size
+	if conf.MinPoolSize > 0 {
+		clientOptions.SetMinPoolSize(conf.MinPoolSize)
+	}
+
+	// set max pool size
+	if conf.MaxPoolSize > 0 {
+		clientOptions.SetMaxPoolSize(conf.MaxPoolSize)
+	}
+
+	// set max idle time ms
+	if conf.MaxIdleTimeMS > 0 {
+		clientOptions.SetMaxConnIdleTime(time.Duration(conf.MaxIdleTimeMS) * time.Millisecond)
+	}
+
+	// construct a connection string from mongo config object
+	cxnString := fmt.Sprintf("mongodb+srv://%s:%s@%s", conf.Username, conf.Password, conf.Host)
+	if conf.URI != "" {
+		cxnString = conf.URI
+	}
+
+	// create mongo client by making new connection
+	return mongo.Connect(ctx, clientOptions.ApplyURI(cxnString))
+}
+
 // createContext create a new context with timeout
 func createContext(ctx context.Context, t uint64) (context.Context, context.CancelFunc) {
 	timeout := time.Duration(t) * time.Millisecond
 	return context.WithTimeout(ctx, timeout*time.Millisecond)
 }
 
-// stringsToUpperCase converts slide of string to slides of lower case string
+// stringsToUpperCase converts slide of string to slides of upper case string
 func stringsToUpperCase(strs []string) (upperStrings []string, err error) {
 	for _, str := range strs {
 		upperString := strings.ToUpper(str)
@@ -2,8 +2,10 @@ package repos
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	logger "github.com/lenoobz/aws-lambda-logger"
@@ -11,17 +13,32 @@ import (
 	"github.com/lenoobz/aws-tiprank-dividend-scraper/consts"
 	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
 	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/repositories/mongodb/models"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/runid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
+// maxPageSize is the largest page of stocks, also the page size when none is given
+const maxPageSize = 500
+
+// maxVersionConflictRetries is how many times a write losing to a concurrent writer is tried again
+const maxVersionConflictRetries = 5
+
+// duplicateKeyCode is the error code of a write breaking a unique index
+const duplicateKeyCode = 11000
+
+// errVersionConflict tells that a document changed since it was read
+var errVersionConflict = errors.New("version conflict")
+
 // TipRankDividendMongo struct
 type TipRankDividendMongo struct {
-	db     *mongo.Database
-	client *mongo.Client
-	log    logger.ContextLog
-	conf   *config.MongoConfig
+	db               *mongo.Database
+	client           *mongo.Client
+	log              logger.ContextLog
+	conf             *config.MongoConfig
+	versionConflicts int64 // writes which lost to a concurrent writer, accessed atomically
 }
 
 // NewTipRankDividendMongo creates new stock mongo repo
@@ -34,34 +51,7 @@ func NewTipRankDividendMongo(db *mongo.Database, log logger.ContextLog, conf *co
 		}, nil
 	}
 
-	// set context with timeout from the config
-	// create new context for the query
-	ctx, cancel := createContext(context.Background(), conf.TimeoutMS)
-	defer cancel()
-
-	// set mongo client options
-	clientOptions := options.Client()
-
-	// set min pool size
-	if conf.MinPoolSize > 0 {
-		clientOptions.SetMinPoolSize(conf.MinPoolSize)
-	}
-
-	// set max pool size
-	if conf.MaxPoolSize > 0 {
-		clientOptions.SetMaxPoolSize(conf.MaxPoolSize)
-	}
-
-	// set max idle time ms
-	if conf.MaxIdleTimeMS > 0 {
-		clientOptions.SetMaxConnIdleTime(time.Duration(conf.MaxIdleTimeMS) * time.Millisecond)
-	}
-
-	// construct a connection string from mongo config object
-	cxnString := fmt.Sprintf("mongodb+srv://%s:%s@%s", conf.Username, conf.Password, conf.Host)
-
-	// create mongo client by making new connection
-	client, err := mongo.Connect(ctx, clientOptions.ApplyURI(cxnString))
+	client, err := connectMongo(conf)
 	if err != nil {
 		return nil, err
 	}
@@ -88,39 +78,631 @@ func (r *TipRankDividendMongo) Close() {
 	}
 }
 
+// GetVersionConflicts gets how many writes lost to a concurrent writer and were tried again
+func (r *TipRankDividendMongo) GetVersionConflicts() int {
+	return int(atomic.LoadInt64(&r.versionConflicts))
+}
+
+// Ping checks whether the database can be reached
+func (r *TipRankDividendMongo) Ping(ctx context.Context) error {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	return r.db.Client().Ping(ctx, readpref.Primary())
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // Implement interface
 ///////////////////////////////////////////////////////////////////////////////
 
-// InsertTipRankDividend insert new Tiprank dividend
-func (r *TipRankDividendMongo) InsertTipRankDividend(ctx context.Context, tiprankDividend *entities.TipRankDividend, currency string) error {
+// GetByTicker gets TipRank dividend of a given ticker, nil if not found
+func (r *TipRankDividendMongo) GetByTicker(ctx context.Context, ticker string) (*entities.DividendStock, error) {
 	// create new context for the query
 	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
 	defer cancel()
 
-	savedTipRankDividend, err := r.findTipRankDividendByTicker(ctx, tiprankDividend.Ticker)
+	tiprankDividendModel, err := r.findTipRankDividendByTicker(ctx, ticker)
 	if err != nil {
-		r.log.Error(ctx, "find stock by ticker failed", "error", err, "ticker", tiprankDividend.Ticker)
-		return err
+		r.log.Error(ctx, "find stock by ticker failed", "error", err, "ticker", ticker)
+		return nil, err
+	}
+
+	if tiprankDividendModel == nil {
+		return nil, nil
 	}
 
-	newTipRankDividend, err := models.NewTipRankDividendModel(ctx, r.log, tiprankDividend, currency, r.conf.SchemaVersion)
+	return tiprankDividendModel.ToEntity(), nil
+}
+
+// GetBySymbol gets TipRank dividend of a given canonical symbol, nil if not found
+func (r *TipRankDividendMongo) GetBySymbol(ctx context.Context, symbol *entities.Symbol) (*entities.DividendStock, error) {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	tiprankDividendModel, err := r.findTipRankDividendBySymbol(ctx, symbol, "")
+	if err != nil {
+		r.log.Error(ctx, "find stock by symbol failed", "error", err, "symbol", symbol.String())
+		return nil, err
+	}
+
+	if tiprankDividendModel == nil {
+		return nil, nil
+	}
+
+	return tiprankDividendModel.ToEntity(), nil
+}
+
+// GetByTickers gets TipRank dividends of given tickers sorted by ticker, tickers without dividends are left out
+func (r *TipRankDividendMongo) GetByTickers(ctx context.Context, tickers []string) ([]*entities.DividendStock, error) {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	// what collection we are going to use
+	colname, ok := r.conf.Colnames[consts.TIPRANK_DIVIDEND_LIST_COLLECTION]
+	if !ok {
+		r.log.Error(ctx, "cannot find collection name")
+		return nil, fmt.Errorf("cannot find collection name")
+	}
+	col := r.db.Collection(colname)
+
+	upperTickers, err := stringsToUpperCase(tickers)
 	if err != nil {
-		// log noncrucial error
-		r.log.Warn(ctx, "create model failed but ignored", "error", err, "ticker", tiprankDividend.Ticker)
+		r.log.Error(ctx, "convert tickers to upper case failed", "error", err)
+		return nil, err
+	}
+
+	// filter
+	filter := bson.D{{
+		Key: "ticker",
+		Value: bson.D{{
+			Key:   "$in",
+			Value: upperTickers,
+		}},
+	}}
+
+	// find options
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "ticker", Value: 1}, {Key: "_id", Value: 1}})
+
+	cur, err := col.Find(ctx, filter, findOptions)
+	if err != nil {
+		r.log.Error(ctx, "find TipRank dividends failed", "error", err, "tickers", upperTickers)
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	tiprankDividendModels, err := r.decodeTipRankDividends(ctx, cur)
+	if err != nil {
+		return nil, err
+	}
+
+	return toDividendStocks(tiprankDividendModels), nil
+}
+
+// ListByExDateRange gets TipRank dividends with an ex-dividend date between from and to dates, both included,
+// each stock only has the dividends of the range. Exchanges filter stocks when given
+func (r *TipRankDividendMongo) ListByExDateRange(ctx context.Context, from time.Time, to time.Time, exchanges []string) ([]*entities.DividendStock, error) {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	return r.listDividendEventsByDateRange(ctx, "exDividendDate", from, to, exchanges)
+}
+
+// ListByPayDateRange gets TipRank dividends with a payout date between from and to dates, both included,
+// each stock only has the dividends of the range. Exchanges filter stocks when given
+func (r *TipRankDividendMongo) ListByPayDateRange(ctx context.Context, from time.Time, to time.Time, exchanges []string) ([]*entities.DividendStock, error) {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	return r.listDividendEventsByDateRange(ctx, "payoutDate", from, to, exchanges)
+}
+
+// ListUpcoming gets TipRank dividends going ex-dividend in the given number of days from today, today included.
+// Exchanges filter stocks when given
+func (r *TipRankDividendMongo) ListUpcoming(ctx context.Context, today time.Time, days int, exchanges []string) ([]*entities.DividendStock, error) {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	// dividend dates are stored at midnight UTC
+	from := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, days)
+
+	return r.listDividendEventsByDateRange(ctx, "exDividendDate", from, to, exchanges)
+}
+
+// ListAll gets a page of TipRank dividends sorted by ticker, cursor is the next cursor of the previous page,
+// empty for the first page
+func (r *TipRankDividendMongo) ListAll(ctx context.Context, cursor string, limit int) (*entities.DividendStockPage, error) {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	// what collection we are going to use
+	colname, ok := r.conf.Colnames[consts.TIPRANK_DIVIDEND_LIST_COLLECTION]
+	if !ok {
+		r.log.Error(ctx, "cannot find collection name")
+		return nil, fmt.Errorf("cannot find collection name")
+	}
+	col := r.db.Collection(colname)
+
+	if limit <= 0 || limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	// filter stocks after the cursor
+	filter := bson.D{}
+	if cursor != "" {
+		ticker, id, err := decodePageCursor(cursor)
+		if err != nil {
+			r.log.Error(ctx, "decode page cursor failed", "error", err)
+			return nil, err
+		}
+
+		filter = bson.D{{
+			Key: "$or",
+			Value: bson.A{
+				bson.D{{
+					Key:   "ticker",
+					Value: bson.D{{Key: "$gt", Value: ticker}},
+				}},
+				bson.D{
+					{Key: "ticker", Value: ticker},
+					{Key: "_id", Value: bson.D{{Key: "$gt", Value: id}}},
+				},
+			},
+		}}
 	}
 
-	if savedTipRankDividend != nil {
-		// Copy dividend history from saved stock to inserting stock
-		for k, v := range savedTipRankDividend.DividendHistory {
-			if _, f := newTipRankDividend.DividendHistory[k]; !f {
-				newTipRankDividend.DividendHistory[k] = v
+	// find options, one more stock tells whether there is a next page
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "ticker", Value: 1}, {Key: "_id", Value: 1}})
+	findOptions.SetLimit(int64(limit) + 1)
+
+	cur, err := col.Find(ctx, filter, findOptions)
+	if err != nil {
+		r.log.Error(ctx, "find TipRank dividends failed", "error", err)
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	tiprankDividendModels, err := r.decodeTipRankDividends(ctx, cur)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &entities.DividendStockPage{}
+	if len(tiprankDividendModels) > limit {
+		tiprankDividendModels = tiprankDividendModels[:limit]
+
+		last := tiprankDividendModels[limit-1]
+		if page.NextCursor, err = encodePageCursor(last.Ticker, last.ID); err != nil {
+			r.log.Error(ctx, "encode page cursor failed", "error", err)
+			return nil, err
+		}
+	}
+
+	page.DividendStocks = toDividendStocks(tiprankDividendModels)
+
+	return page, nil
+}
+
+// InsertTipRankDividends upserts TipRank dividends of a response with a single bulk write. Saved stocks are
+// read at once to skip unchanged dividends, dividends of a same stock are merged into one write and each
+// dividend event is $set on its own key. Saved stocks are only updated at the version they were read at,
+// writes which lost to a concurrent writer are read and written again, so are new stocks a concurrent writer
+// inserted first and whose upsert broke the unique symbol index. A write only counts once no conflict
+// is left or the stock read again has no change left, a stock given up after conflicts fails. Dividend events
+// of stocks are copied before the stocks are written, so stocks whose copy failed are written again by the
+// next run. Results follow the order of the writes, a failed write has no result
+func (r *TipRankDividendMongo) InsertTipRankDividends(ctx context.Context, tiprankDividendWrites []*entities.TipRankDividendWrite) ([]entities.WriteResult, error) {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	results := make([]entities.WriteResult, len(tiprankDividendWrites))
+	if len(tiprankDividendWrites) == 0 {
+		return results, nil
+	}
+
+	// what collection we are going to use
+	colname, ok := r.conf.Colnames[consts.TIPRANK_DIVIDEND_LIST_COLLECTION]
+	if !ok {
+		r.log.Error(ctx, "cannot find collection name")
+		return results, fmt.Errorf("cannot find collection name")
+	}
+	col := r.db.Collection(colname)
+
+	// stockWrite is the write of a stock and the indexes of its dividends, result is the result of
+	// its last write until the write is confirmed as written
+	type stockWrite struct {
+		tiprankDividendWrite *entities.TipRankDividendWrite
+		model                *models.TipRankDividendModel
+		saved                *models.TipRankDividendModel
+		indexes              []int
+		result               entities.WriteResult
+		written              bool
+	}
+
+	// confirm records the result of a stock write which is known to be saved
+	confirm := func(write *stockWrite) {
+		write.written = true
+		for _, i := range write.indexes {
+			results[i] = write.result
+		}
+	}
+
+	var stockWrites []*stockWrite
+	stockWritesBySymbol := map[string]*stockWrite{}
+
+	var failed int
+	for i, tiprankDividendWrite := range tiprankDividendWrites {
+		tiprankDividend := tiprankDividendWrite.TipRankDividend
+		symbol := tiprankDividendWrite.Symbol
+
+		newTipRankDividend, err := models.NewTipRankDividendModel(ctx, r.log, tiprankDividend, symbol, tiprankDividendWrite.Currency, r.conf.SchemaVersion)
+		if newTipRankDividend == nil {
+			r.log.Error(ctx, "create model failed", "error", err, "ticker", tiprankDividend.Ticker)
+			failed++
+			continue
+		}
+
+		if err != nil {
+			// log noncrucial error
+			r.log.Warn(ctx, "create model failed but ignored", "error", err, "ticker", tiprankDividend.Ticker)
+		}
+
+		newTipRankDividend.LastRunID = runid.FromContext(ctx)
+
+		// the last dividend of a stock gives its top level fields, as one write per dividend did
+		if existing, found := stockWritesBySymbol[symbol.String()]; found {
+			for k, v := range existing.model.DividendHistory {
+				if _, f := newTipRankDividend.DividendHistory[k]; !f {
+					newTipRankDividend.DividendHistory[k] = v
+				}
+			}
+
+			existing.model = newTipRankDividend
+			existing.indexes = append(existing.indexes, i)
+			continue
+		}
+
+		write := &stockWrite{
+			tiprankDividendWrite: tiprankDividendWrite,
+			model:                newTipRankDividend,
+			indexes:              []int{i},
+		}
+
+		stockWrites = append(stockWrites, write)
+		stockWritesBySymbol[symbol.String()] = write
+	}
+
+	pending := stockWrites
+	for attempt := 0; len(pending) > 0; attempt++ {
+		// read saved stocks, again after a conflict to get their latest version
+		var pendingWrites []*entities.TipRankDividendWrite
+		for _, write := range pending {
+			pendingWrites = append(pendingWrites, write.tiprankDividendWrite)
+		}
+
+		savedTipRankDividends, err := r.findTipRankDividendsBySymbols(ctx, col, pendingWrites)
+		if err != nil {
+			r.log.Error(ctx, "find stocks by symbols failed", "error", err, "count", len(pendingWrites))
+			return results, err
+		}
+
+		var writeModels []mongo.WriteModel
+		var writtenStocks []*stockWrite
+		for _, write := range pending {
+			write.saved = savedTipRankDividends[write.tiprankDividendWrite.Symbol.String()]
+
+			// a stock having no change left after a previous attempt wrote it is confirmed as written
+			if !write.model.HasChanges(write.saved) {
+				if write.result != "" {
+					confirm(write)
+				} else {
+					r.log.Info(ctx, "TipRank dividend unchanged", "ticker", write.model.Ticker)
+					for _, i := range write.indexes {
+						results[i] = entities.WriteResultUnchanged
+					}
+				}
+				continue
 			}
+
+			// the previous write of a stock still having changes lost to a concurrent writer
+			write.result = ""
+
+			// a conflicting write might have lost again, e.g. to a backfill writing the same stock
+			if attempt > maxVersionConflictRetries {
+				r.log.Error(ctx, "give up TipRank dividend after version conflicts", "ticker", write.model.Ticker, "attempts", attempt)
+				failed += len(write.indexes)
+				continue
+			}
+
+			writeModel := mongo.NewUpdateOneModel().
+				SetFilter(getTipRankDividendFilter(write.model, write.saved)).
+				SetUpdate(write.model.GetUpdate()).
+				SetUpsert(write.saved == nil)
+
+			writeModels = append(writeModels, writeModel)
+			writtenStocks = append(writtenStocks, write)
+		}
+
+		if len(writeModels) == 0 {
+			break
+		}
+
+		// a failed copy leaves the stocks to write without result so that the response is processed again
+		var changedTipRankDividends []*models.TipRankDividendModel
+		for _, write := range writtenStocks {
+			changedTipRankDividends = append(changedTipRankDividends, write.model)
+		}
+
+		if err := r.insertDividendEvents(ctx, changedTipRankDividends); err != nil {
+			return results, err
+		}
+
+		// writes of different stocks do not depend on each other
+		opts := options.BulkWrite().SetOrdered(false)
+
+		res, err := col.BulkWrite(ctx, writeModels, opts)
+
+		failedWrites := map[int]mongo.WriteError{}
+		if err != nil {
+			bulkWriteException, ok := err.(mongo.BulkWriteException)
+			if !ok || bulkWriteException.WriteConcernError != nil {
+				r.log.Error(ctx, "bulk write failed", "error", err, "count", len(writeModels))
+				return results, err
+			}
+
+			for _, writeError := range bulkWriteException.WriteErrors {
+				failedWrites[writeError.Index] = writeError.WriteError
+			}
+		}
+
+		var written []*stockWrite
+		var duplicated []*stockWrite
+		for op, write := range writtenStocks {
+			var upserted bool
+			if res != nil {
+				_, upserted = res.UpsertedIDs[int64(op)]
+			}
+
+			if writeError, f := failedWrites[op]; f {
+				// a concurrent writer inserted the new stock first, it is read and written again
+				if writeError.Code == duplicateKeyCode {
+					duplicated = append(duplicated, write)
+					continue
+				}
+
+				r.log.Error(ctx, "insert TipRank dividend failed", "error", writeError.Message, "ticker", write.model.Ticker)
+				failed += len(write.indexes)
+				continue
+			}
+
+			write.result = entities.WriteResultUpdated
+			if upserted {
+				write.result = entities.WriteResultInserted
+			}
+
+			written = append(written, write)
+		}
+
+		// writes matching no document lost to a concurrent writer, the bulk result does not tell which
+		// so the written stocks are read again, those having no change left are confirmed and the others
+		// are written again
+		conflicts := len(written)
+		if res != nil {
+			conflicts -= int(res.MatchedCount + res.UpsertedCount)
+		}
+
+		pending = duplicated
+		if conflicts > 0 {
+			pending = append(pending, written...)
+		} else {
+			conflicts = 0
+			for _, write := range written {
+				confirm(write)
+			}
+		}
+
+		if len(pending) == 0 {
+			break
+		}
+
+		r.addVersionConflicts(ctx, conflicts+len(duplicated), attempt)
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("%d of %d TipRank dividends failed to be written", failed, len(tiprankDividendWrites))
+	}
+
+	return results, nil
+}
+
+// InsertTipRankDividendHistory merges dividend history of a ticker into its document, it is read and
+// written again when a concurrent writer updated or inserted the document in between. Dividend events
+// are copied before the document is written, so a failed copy leaves the document to the next run
+func (r *TipRankDividendMongo) InsertTipRankDividendHistory(ctx context.Context, ticker string, symbol *entities.Symbol, tiprankDividends []*entities.TipRankDividend, currency string) (entities.WriteResult, error) {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	for attempt := 0; ; attempt++ {
+		savedTipRankDividend, err := r.findTipRankDividendBySymbol(ctx, symbol, ticker)
+		if err != nil {
+			r.log.Error(ctx, "find stock by symbol failed", "error", err, "symbol", symbol.String())
+			return "", err
+		}
+
+		newTipRankDividend := models.NewTipRankDividendHistoryModel(ctx, r.log, ticker, symbol, tiprankDividends, currency, r.conf.SchemaVersion)
+		if len(newTipRankDividend.DividendHistory) == 0 {
+			r.log.Info(ctx, "no dividend history to insert", "ticker", ticker)
+			return entities.WriteResultUnchanged, nil
+		}
+
+		// keep top level fields of the saved stock when it knows a more recent dividend,
+		// saved dividend events are kept by the write
+		if savedTipRankDividend != nil && savedTipRankDividend.GetLatestDividendTime() > newTipRankDividend.GetLatestDividendTime() {
+			newTipRankDividend.Yield = savedTipRankDividend.Yield
+			newTipRankDividend.Amount = savedTipRankDividend.Amount
+		}
+
+		newTipRankDividend.LastRunID = runid.FromContext(ctx)
+
+		if !newTipRankDividend.HasChanges(savedTipRankDividend) {
+			r.log.Info(ctx, "TipRank dividend history unchanged", "ticker", ticker)
+			return entities.WriteResultUnchanged, nil
+		}
+
+		if err := r.insertDividendEvents(ctx, []*models.TipRankDividendModel{newTipRankDividend}); err != nil {
+			return "", err
+		}
+
+		result, err := r.insertTipRankDividend(ctx, newTipRankDividend, savedTipRankDividend)
+		if err == errVersionConflict && attempt < maxVersionConflictRetries {
+			r.addVersionConflicts(ctx, 1, attempt)
+			continue
 		}
+
+		if err != nil {
+			r.log.Error(ctx, "insert TipRank dividend history failed", "error", err, "ticker", ticker)
+			return "", err
+		}
+
+		return result, nil
+	}
+}
+
+// InsertQuarantinedResponse keeps aside a raw TipRank response whose shape drifted, the same response
+// quarantined again is counted on the saved one
+func (r *TipRankDividendMongo) InsertQuarantinedResponse(ctx context.Context, quarantinedResponse *entities.QuarantinedResponse) error {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	// what collection we are going to use
+	colname, ok := r.conf.Colnames[consts.TIPRANK_RESPONSE_QUARANTINE_COLLECTION]
+	if !ok {
+		r.log.Error(ctx, "cannot find collection name")
+		return fmt.Errorf("cannot find collection name")
 	}
+	col := r.db.Collection(colname)
+
+	quarantinedResponseModel := models.NewQuarantinedResponseModel(quarantinedResponse, r.conf.SchemaVersion)
+
+	opts := options.Update().SetUpsert(true)
 
-	if err = r.insertTipRankDividend(ctx, newTipRankDividend); err != nil {
-		r.log.Error(ctx, "insert TipRank dividend failed", "error", err, "ticker", tiprankDividend.Ticker)
+	if _, err := col.UpdateOne(ctx, quarantinedResponseModel.GetFilter(), quarantinedResponseModel.GetUpdate(), opts); err != nil {
+		r.log.Error(ctx, "update one failed", "error", err, "country", quarantinedResponse.Country, "date", quarantinedResponse.Date, "ticker", quarantinedResponse.Ticker)
+		return err
+	}
+
+	return nil
+}
+
+// InsertRejectedDividend keeps aside a TipRank dividend which failed validation
+func (r *TipRankDividendMongo) InsertRejectedDividend(ctx context.Context, rejectedDividend *entities.RejectedDividend) error {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	// what collection we are going to use
+	colname, ok := r.conf.Colnames[consts.TIPRANK_DIVIDEND_REJECT_COLLECTION]
+	if !ok {
+		r.log.Error(ctx, "cannot find collection name")
+		return fmt.Errorf("cannot find collection name")
+	}
+	col := r.db.Collection(colname)
+
+	rejectedDividendModel := models.NewRejectedDividendModel(rejectedDividend, r.conf.SchemaVersion)
+
+	if _, err := col.InsertOne(ctx, rejectedDividendModel); err != nil {
+		r.log.Error(ctx, "insert one failed", "error", err, "country", rejectedDividend.Country, "ticker", rejectedDividendModel.Ticker)
+		return err
+	}
+
+	return nil
+}
+
+// GetResponseHash gets content hash of the last processed response of a given country and date, empty if none
+func (r *TipRankDividendMongo) GetResponseHash(ctx context.Context, country string, date string) (string, error) {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	// what collection we are going to use
+	colname, ok := r.conf.Colnames[consts.TIPRANK_RESPONSE_HASH_COLLECTION]
+	if !ok {
+		r.log.Error(ctx, "cannot find collection name")
+		return "", fmt.Errorf("cannot find collection name")
+	}
+	col := r.db.Collection(colname)
+
+	filter := bson.D{
+		{
+			Key:   "country",
+			Value: country,
+		},
+		{
+			Key:   "date",
+			Value: date,
+		},
+	}
+
+	var responseHashModel models.ResponseHashModel
+	if err := col.FindOne(ctx, filter).Decode(&responseHashModel); err != nil {
+		// ErrNoDocuments means that the filter did not match any documents in the collection
+		if err == mongo.ErrNoDocuments {
+			return "", nil
+		}
+
+		r.log.Error(ctx, "decode find one failed", "error", err, "country", country, "date", date)
+		return "", err
+	}
+
+	return responseHashModel.Hash, nil
+}
+
+// InsertResponseHash saves content hash of the last processed response of a given country and date
+func (r *TipRankDividendMongo) InsertResponseHash(ctx context.Context, country string, date string, hash string) error {
+	// create new context for the query
+	ctx, cancel := createContext(ctx, r.conf.TimeoutMS)
+	defer cancel()
+
+	// what collection we are going to use
+	colname, ok := r.conf.Colnames[consts.TIPRANK_RESPONSE_HASH_COLLECTION]
+	if !ok {
+		r.log.Error(ctx, "cannot find collection name")
+		return fmt.Errorf("cannot find collection name")
+	}
+	col := r.db.Collection(colname)
+
+	filter := bson.D{
+		{
+			Key:   "country",
+			Value: country,
+		},
+		{
+			Key:   "date",
+			Value: date,
+		},
+	}
+
+	update := bson.D{{
+		Key:   "$set",
+		Value: models.NewResponseHashModel(country, date, hash, runid.FromContext(ctx), r.conf.SchemaVersion),
+	}}
+
+	opts := options.Update().SetUpsert(true)
+
+	if _, err := col.UpdateOne(ctx, filter, update, opts); err != nil {
+		r.log.Error(ctx, "update one failed", "error", err, "country", country, "date", date)
 		return err
 	}
 
@@ -167,47 +749,301 @@ func (r *TipRankDividendMongo) findTipRankDividendByTicker(ctx context.Context,
 	return &tiprankDividendModel, nil
 }
 
-// insertTipRankDividend inserts TipRank dividend
-func (r *TipRankDividendMongo) insertTipRankDividend(ctx context.Context, tiprankDividendModel *models.TipRankDividendModel) error {
+// findTipRankDividendBySymbol finds TipRank dividend of a given canonical symbol, when a ticker is given
+// it falls back to the legacy document of the ticker saved before documents had an exchange
+func (r *TipRankDividendMongo) findTipRankDividendBySymbol(ctx context.Context, symbol *entities.Symbol, ticker string) (*models.TipRankDividendModel, error) {
+	// what collection we are going to use
+	colname, ok := r.conf.Colnames[consts.TIPRANK_DIVIDEND_LIST_COLLECTION]
+	if !ok {
+		r.log.Error(ctx, "cannot find collection name")
+		return nil, fmt.Errorf("cannot find collection name")
+	}
+	col := r.db.Collection(colname)
+
+	// filter
+	filter := bson.D{
+		{
+			Key:   "exchange",
+			Value: symbol.Exchange,
+		},
+		{
+			Key:   "symbol",
+			Value: symbol.Symbol,
+		},
+	}
+
+	var tiprankDividendModel models.TipRankDividendModel
+	err := col.FindOne(ctx, filter).Decode(&tiprankDividendModel)
+	if err == nil {
+		return &tiprankDividendModel, nil
+	}
+
+	// ErrNoDocuments means that the filter did not match any documents in the collection
+	if err != mongo.ErrNoDocuments {
+		r.log.Error(ctx, "decode find one failed", "error", err, "symbol", symbol.String())
+		return nil, err
+	}
+
+	if ticker == "" {
+		r.log.Info(ctx, "TipRank dividend not found", "symbol", symbol.String())
+		return nil, nil
+	}
+
+	// legacy filter
+	legacyFilter := bson.D{
+		{
+			Key:   "ticker",
+			Value: strings.ToUpper(ticker),
+		},
+		{
+			Key: "exchange",
+			Value: bson.D{{
+				Key:   "$exists",
+				Value: false,
+			}},
+		},
+	}
+
+	if err := col.FindOne(ctx, legacyFilter).Decode(&tiprankDividendModel); err != nil {
+		if err == mongo.ErrNoDocuments {
+			r.log.Info(ctx, "TipRank dividend not found", "symbol", symbol.String(), "ticker", ticker)
+			return nil, nil
+		}
+
+		r.log.Error(ctx, "decode find one failed", "error", err, "ticker", ticker)
+		return nil, err
+	}
+
+	return &tiprankDividendModel, nil
+}
+
+// insertTipRankDividend upserts TipRank dividend, the saved document if any is updated in place
+// so that legacy documents get their exchange and symbol, saved dividend events are kept.
+// It fails with errVersionConflict when the saved document changed since it was read, or when
+// a concurrent writer inserted the new document first
+func (r *TipRankDividendMongo) insertTipRankDividend(ctx context.Context, tiprankDividendModel *models.TipRankDividendModel, savedTipRankDividendModel *models.TipRankDividendModel) (entities.WriteResult, error) {
 	if tiprankDividendModel == nil {
 		r.log.Error(ctx, "invalid param")
-		return fmt.Errorf("invalid param")
+		return "", fmt.Errorf("invalid param")
 	}
 
 	// what collection we are going to use
 	colname, ok := r.conf.Colnames[consts.TIPRANK_DIVIDEND_LIST_COLLECTION]
 	if !ok {
 		r.log.Error(ctx, "cannot find collection name")
-		return fmt.Errorf("cannot find collection name")
+		return "", fmt.Errorf("cannot find collection name")
 	}
 	col := r.db.Collection(colname)
 
-	filter := bson.D{{
-		Key:   "ticker",
-		Value: tiprankDividendModel.Ticker,
-	}}
+	filter := getTipRankDividendFilter(tiprankDividendModel, savedTipRankDividendModel)
+	update := tiprankDividendModel.GetUpdate()
+
+	// only a new document is upserted, a saved document is updated at the version it was read at
+	opts := options.Update().SetUpsert(savedTipRankDividendModel == nil)
+
+	res, err := col.UpdateOne(ctx, filter, update, opts)
+	if isDuplicateKeyError(err) {
+		return "", errVersionConflict
+	}
 
-	update := bson.D{
+	if err != nil {
+		r.log.Error(ctx, "update one failed", "error", err)
+		return "", err
+	}
+
+	// the saved document changed since it was read
+	if res.MatchedCount == 0 && res.UpsertedCount == 0 {
+		return "", errVersionConflict
+	}
+
+	if res.UpsertedCount > 0 {
+		return entities.WriteResultInserted, nil
+	}
+
+	if res.ModifiedCount > 0 {
+		return entities.WriteResultUpdated, nil
+	}
+
+	return entities.WriteResultUnchanged, nil
+}
+
+// findTipRankDividendsBySymbols finds TipRank dividends of the symbols of given writes keyed on symbol,
+// a symbol without document gets the legacy document of its ticker saved before documents had an exchange
+func (r *TipRankDividendMongo) findTipRankDividendsBySymbols(ctx context.Context, col *mongo.Collection, tiprankDividendWrites []*entities.TipRankDividendWrite) (map[string]*models.TipRankDividendModel, error) {
+	var symbolFilters bson.A
+	var tickers bson.A
+	for _, tiprankDividendWrite := range tiprankDividendWrites {
+		symbolFilters = append(symbolFilters, bson.D{
+			{
+				Key:   "exchange",
+				Value: tiprankDividendWrite.Symbol.Exchange,
+			},
+			{
+				Key:   "symbol",
+				Value: tiprankDividendWrite.Symbol.Symbol,
+			},
+		})
+
+		tickers = append(tickers, strings.ToUpper(tiprankDividendWrite.TipRankDividend.Ticker))
+	}
+
+	// legacy filter
+	legacyFilter := bson.D{
 		{
-			Key:   "$set",
-			Value: tiprankDividendModel,
+			Key: "ticker",
+			Value: bson.D{{
+				Key:   "$in",
+				Value: tickers,
+			}},
 		},
 		{
-			Key: "$setOnInsert",
+			Key: "exchange",
 			Value: bson.D{{
-				Key:   "createdAt",
-				Value: time.Now().UTC().Unix(),
+				Key:   "$exists",
+				Value: false,
 			}},
 		},
 	}
 
-	opts := options.Update().SetUpsert(true)
+	filter := bson.D{{
+		Key:   "$or",
+		Value: append(symbolFilters, legacyFilter),
+	}}
 
-	_, err := col.UpdateOne(ctx, filter, update, opts)
+	cur, err := col.Find(ctx, filter)
 	if err != nil {
-		r.log.Error(ctx, "update one failed", "error", err)
-		return err
+		r.log.Error(ctx, "find TipRank dividends failed", "error", err)
+		return nil, err
 	}
+	defer cur.Close(ctx)
 
-	return nil
+	savedTipRankDividends := map[string]*models.TipRankDividendModel{}
+	legacyTipRankDividends := map[string]*models.TipRankDividendModel{}
+	for cur.Next(ctx) {
+		var tiprankDividendModel models.TipRankDividendModel
+		if err := cur.Decode(&tiprankDividendModel); err != nil {
+			r.log.Error(ctx, "decode TipRank dividend failed", "error", err)
+			return nil, err
+		}
+
+		if tiprankDividendModel.Exchange == "" {
+			legacyTipRankDividends[tiprankDividendModel.Ticker] = &tiprankDividendModel
+			continue
+		}
+
+		symbol := &entities.Symbol{
+			Exchange: tiprankDividendModel.Exchange,
+			Symbol:   tiprankDividendModel.Symbol,
+		}
+		savedTipRankDividends[symbol.String()] = &tiprankDividendModel
+	}
+
+	if err := cur.Err(); err != nil {
+		r.log.Error(ctx, "iterate TipRank dividends failed", "error", err)
+		return nil, err
+	}
+
+	for _, tiprankDividendWrite := range tiprankDividendWrites {
+		key := tiprankDividendWrite.Symbol.String()
+		if _, found := savedTipRankDividends[key]; found {
+			continue
+		}
+
+		if legacy, found := legacyTipRankDividends[strings.ToUpper(tiprankDividendWrite.TipRankDividend.Ticker)]; found {
+			savedTipRankDividends[key] = legacy
+		}
+	}
+
+	return savedTipRankDividends, nil
+}
+
+// getTipRankDividendFilter gets filter of the document of a TipRank dividend, the saved document
+// is matched on its id and version so that legacy documents without exchange are updated in place
+// and a document updated by a concurrent writer is not matched
+func getTipRankDividendFilter(tiprankDividendModel *models.TipRankDividendModel, savedTipRankDividendModel *models.TipRankDividendModel) bson.D {
+	if savedTipRankDividendModel != nil && savedTipRankDividendModel.ID != nil {
+		// documents written before versions have none
+		var version interface{} = savedTipRankDividendModel.Version
+		if savedTipRankDividendModel.Version == 0 {
+			version = bson.D{{
+				Key:   "$exists",
+				Value: false,
+			}}
+		}
+
+		return bson.D{
+			{
+				Key:   "_id",
+				Value: savedTipRankDividendModel.ID,
+			},
+			{
+				Key:   "version",
+				Value: version,
+			},
+		}
+	}
+
+	return bson.D{
+		{
+			Key:   "exchange",
+			Value: tiprankDividendModel.Exchange,
+		},
+		{
+			Key:   "symbol",
+			Value: tiprankDividendModel.Symbol,
+		},
+	}
+}
+
+// isDuplicateKeyError checks whether a single write failed because it broke a unique index
+func isDuplicateKeyError(err error) bool {
+	writeException, ok := err.(mongo.WriteException)
+	if !ok {
+		return false
+	}
+
+	for _, writeError := range writeException.WriteErrors {
+		if writeError.Code == duplicateKeyCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addVersionConflicts counts writes which lost to a concurrent writer of the same document
+func (r *TipRankDividendMongo) addVersionConflicts(ctx context.Context, conflicts int, attempt int) {
+	total := atomic.AddInt64(&r.versionConflicts, int64(conflicts))
+	r.log.Warn(ctx, "TipRank dividend version conflict, retry", "conflicts", conflicts, "attempt", attempt+1, "totalConflicts", total)
+}
+
+// decodeTipRankDividends decodes all TipRank dividends of a cursor
+func (r *TipRankDividendMongo) decodeTipRankDividends(ctx context.Context, cur *mongo.Cursor) ([]*models.TipRankDividendModel, error) {
+	var tiprankDividendModels []*models.TipRankDividendModel
+	for cur.Next(ctx) {
+		var tiprankDividendModel models.TipRankDividendModel
+		if err := cur.Decode(&tiprankDividendModel); err != nil {
+			r.log.Error(ctx, "decode TipRank dividend failed", "error", err)
+			return nil, err
+		}
+
+		tiprankDividendModels = append(tiprankDividendModels, &tiprankDividendModel)
+	}
+
+	if err := cur.Err(); err != nil {
+		r.log.Error(ctx, "iterate TipRank dividends failed", "error", err)
+		return nil, err
+	}
+
+	return tiprankDividendModels, nil
+}
+
+// toDividendStocks converts TipRank dividend models to entities
+func toDividendStocks(tiprankDividendModels []*models.TipRankDividendModel) []*entities.DividendStock {
+	dividendStocks := []*entities.DividendStock{}
+	for _, tiprankDividendModel := range tiprankDividendModels {
+		dividendStocks = append(dividendStocks, tiprankDividendModel.ToEntity())
+	}
+
+	return dividendStocks
 }
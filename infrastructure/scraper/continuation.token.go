@@ -0,0 +1,81 @@
+package scraper
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+)
+
+// continuationTokenVersion is bumped whenever the token payload changes
+const continuationTokenVersion = 1
+
+// continuationToken lists remaining tasks of an interrupted run, grouped by country
+type continuationToken struct {
+	Version int                 `json:"v"`
+	Tasks   map[string][]string `json:"tasks"`
+}
+
+// EncodeContinuationToken encodes remaining tasks into an opaque token
+func EncodeContinuationToken(tasks []*entities.ScrapeTask) (string, error) {
+	if len(tasks) == 0 {
+		return "", nil
+	}
+
+	token := continuationToken{
+		Version: continuationTokenVersion,
+		Tasks:   map[string][]string{},
+	}
+
+	for _, task := range tasks {
+		token.Tasks[task.Country] = append(token.Tasks[task.Country], task.Date)
+	}
+
+	for _, dates := range token.Tasks {
+		sort.Strings(dates)
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeContinuationToken decodes remaining tasks from a token
+func DecodeContinuationToken(encodedToken string) ([]*entities.ScrapeTask, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encodedToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid continuation token: %v", err)
+	}
+
+	var token continuationToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("invalid continuation token: %v", err)
+	}
+
+	if token.Version != continuationTokenVersion {
+		return nil, fmt.Errorf("unsupported continuation token version %d", token.Version)
+	}
+
+	var countries []string
+	for countryCode := range token.Tasks {
+		countries = append(countries, countryCode)
+	}
+	sort.Strings(countries)
+
+	var tasks []*entities.ScrapeTask
+	for _, countryCode := range countries {
+		for _, date := range token.Tasks[countryCode] {
+			tasks = append(tasks, &entities.ScrapeTask{
+				Country: countryCode,
+				Date:    date,
+			})
+		}
+	}
+
+	return tasks, nil
+}
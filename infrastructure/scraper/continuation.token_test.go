@@ -0,0 +1,85 @@
+package scraper
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+)
+
+func TestContinuationTokenRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		tasks []*entities.ScrapeTask
+		want  []*entities.ScrapeTask
+	}{
+		{
+			name: "no task",
+		},
+		{
+			name:  "single task",
+			tasks: []*entities.ScrapeTask{{Country: "US", Date: "2021-03-01"}},
+			want:  []*entities.ScrapeTask{{Country: "US", Date: "2021-03-01"}},
+		},
+		{
+			name: "tasks sorted by country then date",
+			tasks: []*entities.ScrapeTask{
+				{Country: "US", Date: "2021-03-02"},
+				{Country: "Canada", Date: "2021-03-03"},
+				{Country: "US", Date: "2021-03-01"},
+				{Country: "Canada", Date: "2021-03-01"},
+			},
+			want: []*entities.ScrapeTask{
+				{Country: "Canada", Date: "2021-03-01"},
+				{Country: "Canada", Date: "2021-03-03"},
+				{Country: "US", Date: "2021-03-01"},
+				{Country: "US", Date: "2021-03-02"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := EncodeContinuationToken(tt.tasks)
+			if err != nil {
+				t.Fatalf("EncodeContinuationToken() error = %v", err)
+			}
+
+			if len(tt.tasks) == 0 {
+				if token != "" {
+					t.Fatalf("EncodeContinuationToken() = %q, want empty token", token)
+				}
+				return
+			}
+
+			got, err := DecodeContinuationToken(token)
+			if err != nil {
+				t.Fatalf("DecodeContinuationToken() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeContinuationToken() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeContinuationTokenInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "not base64", token: "not a token!"},
+		{name: "not json", token: base64.RawURLEncoding.EncodeToString([]byte("{"))},
+		{name: "unsupported version", token: base64.RawURLEncoding.EncodeToString([]byte(`{"v":2,"tasks":{"US":["2021-03-01"]}}`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeContinuationToken(tt.token); err == nil {
+				t.Errorf("DecodeContinuationToken(%q) error = nil, want an error", tt.token)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package scraper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FixtureStore stores raw TipRank responses of a (country, date) or a (country, ticker) in a directory
+type FixtureStore struct {
+	dir string
+}
+
+// NewFixtureStore creates new fixture store
+func NewFixtureStore(dir string) *FixtureStore {
+	return &FixtureStore{
+		dir: dir,
+	}
+}
+
+// Save writes raw response body of a given country and date
+func (f *FixtureStore) Save(countryCode string, date time.Time, body []byte) error {
+	path := f.getPath(countryCode, date)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, body, 0644)
+}
+
+// Load reads raw response body of a given country and date
+func (f *FixtureStore) Load(countryCode string, date time.Time) ([]byte, error) {
+	return ioutil.ReadFile(f.getPath(countryCode, date))
+}
+
+// SaveHistory writes raw dividend history response body of a given country and ticker
+func (f *FixtureStore) SaveHistory(countryCode string, ticker string, body []byte) error {
+	path := f.getHistoryPath(countryCode, ticker)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, body, 0644)
+}
+
+// LoadHistory reads raw dividend history response body of a given country and ticker
+func (f *FixtureStore) LoadHistory(countryCode string, ticker string) ([]byte, error) {
+	return ioutil.ReadFile(f.getHistoryPath(countryCode, ticker))
+}
+
+// getPath gets fixture file path of a given country and date, e.g. <dir>/Canada/2021-07-26.json
+func (f *FixtureStore) getPath(countryCode string, date time.Time) string {
+	return filepath.Join(f.dir, countryCode, date.Format("2006-01-02")+".json")
+}
+
+// getHistoryPath gets fixture file path of a given country and ticker, e.g. <dir>/Canada/history/TSE_RY.json
+func (f *FixtureStore) getHistoryPath(countryCode string, ticker string) string {
+	name := strings.NewReplacer(":", "_", "/", "_").Replace(strings.ToUpper(ticker))
+	return filepath.Join(f.dir, countryCode, "history", name+".json")
+}
@@ -0,0 +1,138 @@
+package scraper
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/config"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/calendar"
+)
+
+// JobDate is either an absolute date or a number of days relative to the day the job runs
+type JobDate struct {
+	Date   *time.Time
+	Offset int
+}
+
+// AbsoluteDate creates job date of a given date
+func AbsoluteDate(date time.Time) JobDate {
+	return JobDate{
+		Date: &date,
+	}
+}
+
+// RelativeDate creates job date of a given number of days from the day the job runs
+func RelativeDate(days int) JobDate {
+	return JobDate{
+		Offset: days,
+	}
+}
+
+// Resolve gets the actual date of the job date
+func (d JobDate) Resolve(now time.Time) time.Time {
+	if d.Date != nil {
+		return *d.Date
+	}
+
+	return now.AddDate(0, 0, d.Offset)
+}
+
+// JobSpec describes which dates and countries a scrape job covers
+type JobSpec struct {
+	From      JobDate
+	To        JobDate
+	Countries []string
+	Step      int  // number of days between two scraped dates, default to 1
+	AllDays   bool // also scrape weekends and exchange holidays, when no ex-dividend date can occur
+}
+
+// Validate checks whether the job spec is valid
+func (spec JobSpec) Validate(now time.Time) error {
+	if spec.Step < 0 {
+		return fmt.Errorf("invalid step %d", spec.Step)
+	}
+
+	from := spec.From.Resolve(now)
+	to := spec.To.Resolve(now)
+	if toDay(from).After(toDay(to)) {
+		return fmt.Errorf("from date %s is after to date %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+
+	for _, country := range spec.Countries {
+		market, err := config.GetMarket(country)
+		if err != nil {
+			return err
+		}
+
+		if !market.Enabled {
+			return fmt.Errorf("market %s is not enabled", country)
+		}
+	}
+
+	return nil
+}
+
+// GetCountries gets countries of the job spec, default to all enabled markets
+func (spec JobSpec) GetCountries() []string {
+	if len(spec.Countries) == 0 {
+		return config.GetEnabledMarketCodes()
+	}
+
+	return spec.Countries
+}
+
+// GetDates gets all dates covered by the job spec
+func (spec JobSpec) GetDates(now time.Time) []time.Time {
+	step := spec.Step
+	if step == 0 {
+		step = 1
+	}
+
+	from := toDay(spec.From.Resolve(now))
+	to := toDay(spec.To.Resolve(now))
+
+	var dates []time.Time
+	for date := from; !date.After(to); date = date.AddDate(0, 0, step) {
+		dates = append(dates, date)
+	}
+
+	return dates
+}
+
+// GetTasks gets (country, date) tasks covered by the job spec, relative dates are resolved in the timezone
+// of each market and non-trading days of the market are skipped unless all days are requested
+func (spec JobSpec) GetTasks(now time.Time) []*entities.ScrapeTask {
+	var tasks []*entities.ScrapeTask
+	for _, countryCode := range spec.GetCountries() {
+		marketNow := now
+		var marketCalendar *calendar.Calendar
+		if market, err := config.GetMarket(countryCode); err == nil {
+			if location, err := market.GetLocation(); err == nil {
+				marketNow = now.In(location)
+			}
+
+			if c, err := calendar.GetCalendar(market.Calendar); err == nil {
+				marketCalendar = c
+			}
+		}
+
+		for _, date := range spec.GetDates(marketNow) {
+			if !spec.AllDays && marketCalendar != nil && !marketCalendar.IsBusinessDay(date) {
+				continue
+			}
+
+			tasks = append(tasks, &entities.ScrapeTask{
+				Country: countryCode,
+				Date:    date.Format("2006-01-02"),
+			})
+		}
+	}
+
+	return tasks
+}
+
+// toDay truncates the time part of a given date
+func toDay(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+}
@@ -0,0 +1,173 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+)
+
+func TestJobSpecGetDates(t *testing.T) {
+	now := time.Date(2021, 7, 7, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		spec JobSpec
+		want []string
+	}{
+		{
+			name: "single day",
+			spec: JobSpec{From: RelativeDate(0), To: RelativeDate(0)},
+			want: []string{"2021-07-07"},
+		},
+		{
+			name: "relative range",
+			spec: JobSpec{From: RelativeDate(-2), To: RelativeDate(0)},
+			want: []string{"2021-07-05", "2021-07-06", "2021-07-07"},
+		},
+		{
+			name: "step skips dates",
+			spec: JobSpec{
+				From: AbsoluteDate(time.Date(2021, 5, 3, 0, 0, 0, 0, time.UTC)),
+				To:   AbsoluteDate(time.Date(2021, 5, 7, 0, 0, 0, 0, time.UTC)),
+				Step: 2,
+			},
+			want: []string{"2021-05-03", "2021-05-05", "2021-05-07"},
+		},
+		{
+			name: "time of day is ignored",
+			spec: JobSpec{
+				From: AbsoluteDate(time.Date(2021, 5, 3, 23, 0, 0, 0, time.UTC)),
+				To:   AbsoluteDate(time.Date(2021, 5, 4, 1, 0, 0, 0, time.UTC)),
+			},
+			want: []string{"2021-05-03", "2021-05-04"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, date := range tt.spec.GetDates(now) {
+				got = append(got, date.Format("2006-01-02"))
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetDates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobSpecGetTasks(t *testing.T) {
+	// evening of July 6th in New York is already July 7th in London
+	now := time.Date(2021, 7, 7, 2, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		spec JobSpec
+		want []*entities.ScrapeTask
+	}{
+		{
+			name: "exchange holiday is skipped",
+			spec: JobSpec{
+				From:      AbsoluteDate(time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC)),
+				To:        AbsoluteDate(time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC)),
+				Countries: []string{"Canada"},
+			},
+		},
+		{
+			name: "all days keep exchange holidays",
+			spec: JobSpec{
+				From:      AbsoluteDate(time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC)),
+				To:        AbsoluteDate(time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC)),
+				Countries: []string{"Canada"},
+				AllDays:   true,
+			},
+			want: []*entities.ScrapeTask{{Country: "Canada", Date: "2021-07-01"}},
+		},
+		{
+			name: "weekend and holiday are skipped",
+			spec: JobSpec{
+				From:      AbsoluteDate(time.Date(2021, 7, 2, 0, 0, 0, 0, time.UTC)),
+				To:        AbsoluteDate(time.Date(2021, 7, 6, 0, 0, 0, 0, time.UTC)),
+				Countries: []string{"US"},
+			},
+			want: []*entities.ScrapeTask{
+				{Country: "US", Date: "2021-07-02"},
+				{Country: "US", Date: "2021-07-06"},
+			},
+		},
+		{
+			name: "step skips dates",
+			spec: JobSpec{
+				From:      AbsoluteDate(time.Date(2021, 5, 3, 0, 0, 0, 0, time.UTC)),
+				To:        AbsoluteDate(time.Date(2021, 5, 7, 0, 0, 0, 0, time.UTC)),
+				Countries: []string{"UK"},
+				Step:      2,
+			},
+			want: []*entities.ScrapeTask{
+				{Country: "UK", Date: "2021-05-05"},
+				{Country: "UK", Date: "2021-05-07"},
+			},
+		},
+		{
+			name: "relative dates are resolved in the market timezone",
+			spec: JobSpec{
+				From:      RelativeDate(0),
+				To:        RelativeDate(0),
+				Countries: []string{"US", "UK"},
+			},
+			want: []*entities.ScrapeTask{
+				{Country: "US", Date: "2021-07-06"},
+				{Country: "UK", Date: "2021-07-07"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.spec.GetTasks(now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetTasks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobSpecValidate(t *testing.T) {
+	now := time.Date(2021, 7, 7, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		spec    JobSpec
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			spec: JobSpec{From: RelativeDate(-7), To: RelativeDate(0), Countries: []string{"US"}},
+		},
+		{
+			name:    "from after to",
+			spec:    JobSpec{From: RelativeDate(1), To: RelativeDate(0)},
+			wantErr: true,
+		},
+		{
+			name:    "negative step",
+			spec:    JobSpec{From: RelativeDate(0), To: RelativeDate(0), Step: -1},
+			wantErr: true,
+		},
+		{
+			name:    "unknown country",
+			spec:    JobSpec{From: RelativeDate(0), To: RelativeDate(0), Countries: []string{"Mars"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.spec.Validate(now); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
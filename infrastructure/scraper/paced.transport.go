@@ -0,0 +1,70 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// PaceFunc is called before a request is sent, the request is not sent when it fails
+type PaceFunc func(req *http.Request) error
+
+// pacedTransport is a transport calling a pace function before sending each request. Colly calls request
+// handlers as soon as a request is queued, the transport is only called once the request holds a parallelism
+// slot of the collector. The request timeout starts once the request is paced, time spent waiting for the
+// pace function never times a request out
+type pacedTransport struct {
+	transport http.RoundTripper
+	pace      PaceFunc
+	timeout   time.Duration
+}
+
+// newPacedTransport creates new paced transport, zero timeout means no timeout
+func newPacedTransport(transport http.RoundTripper, pace PaceFunc, timeout time.Duration) *pacedTransport {
+	return &pacedTransport{
+		transport: transport,
+		pace:      pace,
+		timeout:   timeout,
+	}
+}
+
+// RoundTrip waits for the pace function then sends the request through the wrapped transport
+func (t *pacedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.pace(req); err != nil {
+		return nil, err
+	}
+
+	if t.timeout <= 0 {
+		return t.transport.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+
+	res, err := t.transport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// the timeout covers reading the body too
+	res.Body = &cancelBody{
+		ReadCloser: res.Body,
+		cancel:     cancel,
+	}
+
+	return res, nil
+}
+
+// cancelBody is a response body cancelling the context of its request once it is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body then cancels the context of its request
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
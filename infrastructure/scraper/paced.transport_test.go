@@ -0,0 +1,74 @@
+package scraper
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTripFunc is a transport calling a function
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestPacedTransport(t *testing.T) {
+	tests := []struct {
+		name     string
+		pace     PaceFunc
+		wantSent bool
+		wantErr  error
+	}{
+		{
+			name:     "paced request is sent",
+			pace:     func(req *http.Request) error { return nil },
+			wantSent: true,
+		},
+		{
+			name:     "timeout starts once the request is paced",
+			pace:     func(req *http.Request) error { time.Sleep(100 * time.Millisecond); return nil },
+			wantSent: true,
+		},
+		{
+			name:    "request refused by the pace function is not sent",
+			pace:    func(req *http.Request) error { return errRunStopped },
+			wantErr: errRunStopped,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := false
+			transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				sent = true
+				if err := req.Context().Err(); err != nil {
+					return nil, err
+				}
+
+				return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader("{}"))}, nil
+			})
+
+			req, err := http.NewRequest(http.MethodGet, "https://www.tipranks.com/api/dividends", nil)
+			if err != nil {
+				t.Fatalf("create request failed: %v", err)
+			}
+
+			res, err := newPacedTransport(transport, tt.pace, 50*time.Millisecond).RoundTrip(req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RoundTrip() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if sent != tt.wantSent {
+				t.Errorf("request sent = %v, want %v", sent, tt.wantSent)
+			}
+
+			if res != nil {
+				res.Body.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExhausted is returned once a run has sent its maximum number of requests
+var ErrBudgetExhausted = errors.New("request budget exhausted")
+
+// AdaptiveRateLimiter is a token bucket whose rate halves when TipRank throttles us or slowly degrades,
+// and grows back while responses are healthy. It also enforces a maximum number of requests per run
+type AdaptiveRateLimiter struct {
+	rate             float64 // requests per second
+	paced            bool    // false when created with a zero rate, the rate then never changes
+	minRate          float64
+	maxRate          float64
+	burst            float64
+	tokens           float64
+	last             time.Time
+	pausedUntil      time.Time
+	latencyThreshold time.Duration
+	maxRequests      int // zero means no budget
+	requests         int
+	mu               sync.Mutex
+}
+
+// NewAdaptiveRateLimiter creates new adaptive rate limiter
+func NewAdaptiveRateLimiter(rate float64, minRate float64, maxRate float64, burst int, latencyThreshold time.Duration, maxRequests int) *AdaptiveRateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &AdaptiveRateLimiter{
+		rate:             rate,
+		paced:            rate > 0,
+		minRate:          math.Min(minRate, rate),
+		maxRate:          math.Max(maxRate, rate),
+		burst:            float64(burst),
+		tokens:           float64(burst),
+		last:             time.Now(),
+		latencyThreshold: latencyThreshold,
+		maxRequests:      maxRequests,
+	}
+}
+
+// Wait blocks until a request can be sent, it fails when the budget is exhausted or the context is done
+func (l *AdaptiveRateLimiter) Wait(ctx context.Context) error {
+	delay, err := l.reserve()
+	if err != nil {
+		return err
+	}
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token and gets how long to wait until it is available
+func (l *AdaptiveRateLimiter) reserve() (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxRequests > 0 && l.requests >= l.maxRequests {
+		return 0, ErrBudgetExhausted
+	}
+	l.requests++
+
+	now := time.Now()
+	var delay time.Duration
+
+	if l.paced {
+		// refill tokens, they go negative while requests are queued
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		l.tokens--
+
+		if l.tokens < 0 {
+			delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+		}
+	}
+
+	if pause := l.pausedUntil.Sub(now); pause > delay {
+		delay = pause
+	}
+
+	return delay, nil
+}
+
+// OnSuccess speeds up by a tenth of the max rate when a response is fast, slows down when it is slow.
+// Latency is measured from when the request is sent, not from when it is queued
+func (l *AdaptiveRateLimiter) OnSuccess(latency time.Duration) {
+	if l.latencyThreshold > 0 && latency > l.latencyThreshold {
+		l.slowDown(0.75)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.paced {
+		return
+	}
+
+	l.rate = math.Min(l.maxRate, l.rate+l.maxRate/10)
+}
+
+// OnFailure slows down after a server error or a timeout
+func (l *AdaptiveRateLimiter) OnFailure() {
+	l.slowDown(0.75)
+}
+
+// OnThrottled halves the rate and pauses all requests for the retry after duration asked by TipRank,
+// requests are paused even without pacing
+func (l *AdaptiveRateLimiter) OnThrottled(retryAfter time.Duration) {
+	l.slowDown(0.5)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := time.Now().Add(retryAfter); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// GetRate gets current rate in requests per second
+func (l *AdaptiveRateLimiter) GetRate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.rate
+}
+
+// GetRequests gets number of requests sent so far
+func (l *AdaptiveRateLimiter) GetRequests() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.requests
+}
+
+// slowDown multiplies the rate by a given factor without going under the min rate
+func (l *AdaptiveRateLimiter) slowDown(factor float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.paced {
+		return
+	}
+
+	l.rate = math.Max(l.minRate, l.rate*factor)
+}
@@ -0,0 +1,139 @@
+package scraper
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveRateLimiterRate(t *testing.T) {
+	tests := []struct {
+		name   string
+		rate   float64
+		events func(l *AdaptiveRateLimiter)
+		want   float64
+	}{
+		{
+			name:   "fast response speeds up by a tenth of max rate",
+			rate:   1,
+			events: func(l *AdaptiveRateLimiter) { l.OnSuccess(time.Second) },
+			want:   1.2,
+		},
+		{
+			name: "speed up is capped at max rate",
+			rate: 1,
+			events: func(l *AdaptiveRateLimiter) {
+				for i := 0; i < 10; i++ {
+					l.OnSuccess(time.Second)
+				}
+			},
+			want: 2,
+		},
+		{
+			name:   "slow response slows down",
+			rate:   1,
+			events: func(l *AdaptiveRateLimiter) { l.OnSuccess(10 * time.Second) },
+			want:   0.75,
+		},
+		{
+			name:   "failure slows down",
+			rate:   1,
+			events: func(l *AdaptiveRateLimiter) { l.OnFailure() },
+			want:   0.75,
+		},
+		{
+			name:   "throttling halves the rate",
+			rate:   1,
+			events: func(l *AdaptiveRateLimiter) { l.OnThrottled(0) },
+			want:   0.5,
+		},
+		{
+			name: "slow down is capped at min rate",
+			rate: 1,
+			events: func(l *AdaptiveRateLimiter) {
+				for i := 0; i < 10; i++ {
+					l.OnThrottled(0)
+				}
+			},
+			want: 0.1,
+		},
+		{
+			name: "zero rate stays unpaced",
+			rate: 0,
+			events: func(l *AdaptiveRateLimiter) {
+				l.OnSuccess(time.Second)
+				l.OnSuccess(10 * time.Second)
+				l.OnFailure()
+				l.OnThrottled(0)
+			},
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewAdaptiveRateLimiter(tt.rate, 0.1, 2, 1, 5*time.Second, 0)
+			tt.events(l)
+
+			if got := l.GetRate(); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("GetRate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdaptiveRateLimiterReserve(t *testing.T) {
+	tests := []struct {
+		name      string
+		rate      float64
+		burst     int
+		throttled time.Duration
+		requests  int
+		wantDelay bool
+	}{
+		{name: "burst is not delayed", rate: 1, burst: 2, requests: 2, wantDelay: false},
+		{name: "request over burst is delayed", rate: 1, burst: 1, requests: 2, wantDelay: true},
+		{name: "zero rate is not delayed", rate: 0, burst: 1, requests: 10, wantDelay: false},
+		{name: "throttling pauses a zero rate", rate: 0, burst: 1, throttled: time.Minute, requests: 1, wantDelay: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewAdaptiveRateLimiter(tt.rate, 0.1, 2, tt.burst, 0, 0)
+			if tt.throttled > 0 {
+				l.OnThrottled(tt.throttled)
+			}
+
+			var delay time.Duration
+			for i := 0; i < tt.requests; i++ {
+				var err error
+				if delay, err = l.reserve(); err != nil {
+					t.Fatalf("reserve() error = %v", err)
+				}
+			}
+
+			if got := delay > 0; got != tt.wantDelay {
+				t.Errorf("reserve() delay = %v, want delayed %v", delay, tt.wantDelay)
+			}
+		})
+	}
+}
+
+func TestAdaptiveRateLimiterBudget(t *testing.T) {
+	l := NewAdaptiveRateLimiter(0, 0, 0, 1, 0, 2)
+
+	for i := 0; i < 2; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+
+	if err := l.Wait(context.Background()); err != ErrBudgetExhausted {
+		t.Errorf("Wait() error = %v, want %v", err, ErrBudgetExhausted)
+	}
+
+	if got := l.GetRequests(); got != 2 {
+		t.Errorf("GetRequests() = %d, want 2", got)
+	}
+}
@@ -0,0 +1,63 @@
+package scraper
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RequestTimer records when requests are actually sent, after colly queued and delayed them,
+// keyed by request url. It is safe for concurrent use
+type RequestTimer struct {
+	sent map[string]time.Time
+	mu   sync.Mutex
+}
+
+// NewRequestTimer creates new request timer
+func NewRequestTimer() *RequestTimer {
+	return &RequestTimer{
+		sent: map[string]time.Time{},
+	}
+}
+
+// Wrap wraps a transport so that it records when each request is sent
+func (t *RequestTimer) Wrap(transport http.RoundTripper) http.RoundTripper {
+	return &timedTransport{
+		transport: transport,
+		timer:     t,
+	}
+}
+
+// Elapsed gets how long ago the request of a url was sent and forgets it, zero if it was never sent
+func (t *RequestTimer) Elapsed(requestURL string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sent, ok := t.sent[requestURL]
+	if !ok {
+		return 0
+	}
+
+	delete(t.sent, requestURL)
+	return time.Since(sent)
+}
+
+// start records that the request of a url is sent now, a retry of the url replaces its previous time
+func (t *RequestTimer) start(requestURL string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sent[requestURL] = time.Now()
+}
+
+// timedTransport is a transport recording when requests are sent
+type timedTransport struct {
+	transport http.RoundTripper
+	timer     *RequestTimer
+}
+
+// RoundTrip records the request then sends it through the wrapped transport
+func (t *timedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.timer.start(req.URL.String())
+	return t.transport.RoundTrip(req)
+}
@@ -0,0 +1,116 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gocolly/colly"
+)
+
+// ErrorClass classifies why a request failed
+type ErrorClass string
+
+// Error classes of failed requests
+const (
+	ErrorClassTimeout     ErrorClass = "timeout"
+	ErrorClassRateLimited ErrorClass = "rate_limited"
+	ErrorClassServer      ErrorClass = "server_error"
+	ErrorClassNetwork     ErrorClass = "network_error"
+	ErrorClassClient      ErrorClass = "client_error"
+)
+
+// IsTransient checks whether a request failed with this error class is worth retrying
+func (c ErrorClass) IsTransient() bool {
+	return c != ErrorClassClient
+}
+
+// classifyError gets error class of a failed response
+func classifyError(r *colly.Response, err error) ErrorClass {
+	switch {
+	case r.StatusCode == http.StatusTooManyRequests:
+		return ErrorClassRateLimited
+	case r.StatusCode == http.StatusRequestTimeout:
+		return ErrorClassTimeout
+	case r.StatusCode >= 500:
+		return ErrorClassServer
+	case r.StatusCode >= 400:
+		return ErrorClassClient
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrorClassTimeout
+	}
+
+	if r.StatusCode > 0 {
+		return ErrorClassClient
+	}
+
+	return ErrorClassNetwork
+}
+
+// RetryPolicy decides how many times and how long to wait before retrying a failed request
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// Backoff gets delay before a given retry attempt (starting at 0) using exponential backoff with jitter,
+// the delay is never shorter than the retry after duration asked by the server
+func (p *RetryPolicy) Backoff(attempt int, retryAfter time.Duration) time.Duration {
+	// computed in float so that large attempts cannot overflow
+	delay := p.MaxDelay
+	if backoff := float64(p.BaseDelay) * math.Pow(2, float64(attempt)); backoff < float64(p.MaxDelay) {
+		delay = time.Duration(backoff)
+	}
+
+	// equal jitter: keep half of the delay and randomize the other half
+	if half := int64(delay / 2); half > 0 {
+		delay = time.Duration(half + rand.Int63n(half))
+	}
+
+	if retryAfter > delay {
+		return retryAfter
+	}
+
+	return delay
+}
+
+// getRetryAfter gets the Retry-After header of a response, either in seconds or as a http date
+func getRetryAfter(r *colly.Response) time.Duration {
+	if r.Headers == nil {
+		return 0
+	}
+
+	value := r.Headers.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+
+	return 0
+}
+
+// getAttempt gets how many times a request has been retried
+func getAttempt(reqContext *colly.Context) int {
+	attempt, ok := reqContext.GetAny("attempt").(int)
+	if !ok {
+		return 0
+	}
+
+	return attempt
+}
@@ -0,0 +1,92 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gocolly/colly"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{
+		MaxRetries: 3,
+		BaseDelay:  time.Second,
+		MaxDelay:   30 * time.Second,
+	}
+
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter time.Duration
+		min        time.Duration
+		max        time.Duration
+	}{
+		{name: "first attempt", attempt: 0, min: 500 * time.Millisecond, max: time.Second},
+		{name: "doubles each attempt", attempt: 3, min: 4 * time.Second, max: 8 * time.Second},
+		{name: "capped at max delay", attempt: 10, min: 15 * time.Second, max: 30 * time.Second},
+		{name: "large attempt does not overflow", attempt: 1000, min: 15 * time.Second, max: 30 * time.Second},
+		{name: "retry after longer than backoff", attempt: 0, retryAfter: 45 * time.Second, min: 45 * time.Second, max: 45 * time.Second},
+		{name: "retry after shorter than backoff", attempt: 3, retryAfter: time.Second, min: 4 * time.Second, max: 8 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// jitter randomizes the delay so it is checked several times
+			for i := 0; i < 100; i++ {
+				delay := policy.Backoff(tt.attempt, tt.retryAfter)
+				if delay < tt.min || delay > tt.max {
+					t.Fatalf("Backoff(%d, %v) = %v, want between %v and %v", tt.attempt, tt.retryAfter, delay, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorClassIsTransient(t *testing.T) {
+	tests := []struct {
+		errorClass ErrorClass
+		want       bool
+	}{
+		{errorClass: ErrorClassTimeout, want: true},
+		{errorClass: ErrorClassRateLimited, want: true},
+		{errorClass: ErrorClassServer, want: true},
+		{errorClass: ErrorClassNetwork, want: true},
+		{errorClass: ErrorClassClient, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.errorClass), func(t *testing.T) {
+			if got := tt.errorClass.IsTransient(); got != tt.want {
+				t.Errorf("IsTransient() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		err        error
+		want       ErrorClass
+	}{
+		{name: "too many requests", statusCode: http.StatusTooManyRequests, want: ErrorClassRateLimited},
+		{name: "request timeout", statusCode: http.StatusRequestTimeout, want: ErrorClassTimeout},
+		{name: "bad gateway", statusCode: http.StatusBadGateway, want: ErrorClassServer},
+		{name: "not found", statusCode: http.StatusNotFound, want: ErrorClassClient},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, want: ErrorClassTimeout},
+		{name: "connection refused", err: errors.New("connection refused"), want: ErrorClassNetwork},
+		{name: "unexpected status", statusCode: http.StatusNoContent, err: errors.New("unexpected"), want: ErrorClassClient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyError(&colly.Response{StatusCode: tt.statusCode}, tt.err); got != tt.want {
+				t.Errorf("classifyError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
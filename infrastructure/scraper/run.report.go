@@ -0,0 +1,271 @@
+package scraper
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+)
+
+// RunReport summarizes a scraper run, it is safe for concurrent use
+type RunReport struct {
+	RunID             string                  `json:"runId"`
+	DryRun            bool                    `json:"dryRun"`
+	StartedAt         time.Time               `json:"startedAt"`
+	EndedAt           time.Time               `json:"endedAt"`
+	RequestsByCountry map[string]int          `json:"requestsByCountry"`
+	RequestsByDate    map[string]int          `json:"requestsByDate"`
+	HTTPFailures      []FailedRequest         `json:"httpFailures"`
+	ParseFailures     []ParseFailure          `json:"parseFailures"`
+	Drift             *entities.ResponseDrift `json:"drift"`
+	Quarantined       int                     `json:"quarantined"`
+	SkippedResponses  int                     `json:"skippedResponses"`
+	Parsed            int                     `json:"parsed"`
+	Inserted          int                     `json:"inserted"`
+	Updated           int                     `json:"updated"`
+	Unchanged         int                     `json:"unchanged"`
+	Rejected          int                     `json:"rejected"`
+	VersionConflicts  int                     `json:"versionConflicts"`
+	FailedTickers     []FailedTicker          `json:"failedTickers"`
+	BudgetExhausted   bool                    `json:"budgetExhausted"`
+	Partial           bool                    `json:"partial"`
+	Remaining         int                     `json:"remaining"`
+	ContinuationToken string                  `json:"continuationToken,omitempty"`
+	RemainingTickers  []string                `json:"remainingTickers,omitempty"`
+	Proxies           map[string]*ProxyStats  `json:"proxies,omitempty"`
+	remainingTasks    []*entities.ScrapeTask
+	mu                sync.Mutex
+}
+
+// ParseFailure describes a (country, date) or (country, ticker) response that could not be parsed
+type ParseFailure struct {
+	Country string `json:"country"`
+	Date    string `json:"date,omitempty"`
+	Ticker  string `json:"ticker,omitempty"`
+	Error   string `json:"error"`
+}
+
+// ProxyStats counts requests and failures of a proxy
+type ProxyStats struct {
+	Requests int `json:"requests"`
+	Failures int `json:"failures"`
+	Benched  int `json:"benched"`
+}
+
+// FailedTicker describes a ticker that could not be saved
+type FailedTicker struct {
+	Ticker  string `json:"ticker"`
+	Country string `json:"country"`
+	Date    string `json:"date,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// NewRunReport creates new run report
+func NewRunReport(runID string) *RunReport {
+	return &RunReport{
+		RunID:             runID,
+		StartedAt:         time.Now().UTC(),
+		RequestsByCountry: map[string]int{},
+		RequestsByDate:    map[string]int{},
+		Drift:             entities.NewResponseDrift(),
+		Proxies:           map[string]*ProxyStats{},
+	}
+}
+
+// AddRequest counts a request of a given country and date, date is empty for dividend history requests
+func (r *RunReport) AddRequest(countryCode string, date string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.RequestsByCountry[countryCode]++
+	if date != "" {
+		r.RequestsByDate[date]++
+	}
+}
+
+// AddProxyRequest counts a request sent through a proxy
+func (r *RunReport) AddProxyRequest(proxyName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.getProxyStats(proxyName).Requests++
+}
+
+// AddProxyFailure counts a failed request of a proxy
+func (r *RunReport) AddProxyFailure(proxyName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.getProxyStats(proxyName).Failures++
+}
+
+// AddBenchedProxy counts a proxy benched for a cooldown
+func (r *RunReport) AddBenchedProxy(proxyName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.getProxyStats(proxyName).Benched++
+}
+
+// getProxyStats gets stats of a proxy, the caller holds the lock
+func (r *RunReport) getProxyStats(proxyName string) *ProxyStats {
+	stats, ok := r.Proxies[proxyName]
+	if !ok {
+		stats = &ProxyStats{}
+		r.Proxies[proxyName] = stats
+	}
+
+	return stats
+}
+
+// AddHTTPFailure records a request that failed after all retries
+func (r *RunReport) AddHTTPFailure(failedRequest FailedRequest) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.HTTPFailures = append(r.HTTPFailures, failedRequest)
+}
+
+// AddParseFailure records a response that could not be parsed
+func (r *RunReport) AddParseFailure(countryCode string, date string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ParseFailures = append(r.ParseFailures, ParseFailure{
+		Country: countryCode,
+		Date:    date,
+		Error:   err.Error(),
+	})
+}
+
+// AddHistoryParseFailure records a dividend history response that could not be parsed
+func (r *RunReport) AddHistoryParseFailure(countryCode string, ticker string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ParseFailures = append(r.ParseFailures, ParseFailure{
+		Country: countryCode,
+		Ticker:  ticker,
+		Error:   err.Error(),
+	})
+}
+
+// AddDrift adds drift of a response
+func (r *RunReport) AddDrift(drift *entities.ResponseDrift) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Drift.Merge(drift)
+}
+
+// AddQuarantined counts a quarantined response
+func (r *RunReport) AddQuarantined() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Quarantined++
+}
+
+// AddSkippedResponse counts a response skipped because it is identical to the last processed one
+func (r *RunReport) AddSkippedResponse() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.SkippedResponses++
+}
+
+// AddParsed counts dividends parsed from a response
+func (r *RunReport) AddParsed(count int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Parsed += count
+}
+
+// AddWriteResult counts a saved dividend
+func (r *RunReport) AddWriteResult(result entities.WriteResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch result {
+	case entities.WriteResultInserted:
+		r.Inserted++
+	case entities.WriteResultUpdated:
+		r.Updated++
+	case entities.WriteResultUnchanged:
+		r.Unchanged++
+	case entities.WriteResultRejected:
+		r.Rejected++
+	}
+}
+
+// AddRejected counts dividends rejected by validation, e.g. dividends of a dividend history
+func (r *RunReport) AddRejected(count int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Rejected += count
+}
+
+// SetVersionConflicts records how many writes lost to a concurrent writer and were tried again
+func (r *RunReport) SetVersionConflicts(conflicts int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.VersionConflicts = conflicts
+}
+
+// AddFailedTicker records a ticker that could not be saved
+func (r *RunReport) AddFailedTicker(failedTicker FailedTicker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.FailedTickers = append(r.FailedTickers, failedTicker)
+}
+
+// AddRemainingTask records a task left for the next run
+func (r *RunReport) AddRemainingTask(task *entities.ScrapeTask) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.remainingTasks = append(r.remainingTasks, task)
+}
+
+// AddRemainingTicker records a ticker whose dividend history is left for the next run
+func (r *RunReport) AddRemainingTicker(ticker string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.RemainingTickers = append(r.RemainingTickers, ticker)
+}
+
+// SetBudgetExhausted records that the run used all its requests, it returns true only the first time
+func (r *RunReport) SetBudgetExhausted() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.BudgetExhausted {
+		return false
+	}
+
+	r.BudgetExhausted = true
+	return true
+}
+
+// Finish marks the end of the run and creates continuation token of the remaining tasks if any
+func (r *RunReport) Finish() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.EndedAt = time.Now().UTC()
+	r.Partial = len(r.remainingTasks) > 0 || len(r.RemainingTickers) > 0
+	r.Remaining = len(r.remainingTasks)
+
+	token, err := EncodeContinuationToken(r.remainingTasks)
+	if err != nil {
+		return err
+	}
+
+	r.ContinuationToken = token
+	return nil
+}
@@ -2,7 +2,12 @@ package scraper
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gocolly/colly"
@@ -11,226 +16,948 @@ import (
 	corid "github.com/lenoobz/aws-lambda-corid"
 	logger "github.com/lenoobz/aws-lambda-logger"
 	"github.com/lenoobz/aws-tiprank-dividend-scraper/config"
-	"github.com/lenoobz/aws-tiprank-dividend-scraper/consts"
 	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
 	"github.com/lenoobz/aws-tiprank-dividend-scraper/usecase/tiprank"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/hash"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/runid"
 )
 
 // TipRankDividendScraper struct
 type TipRankDividendScraper struct {
 	ScrapeTipRankDividendJob *colly.Collector
+	ScrapeTipRankHistoryJob  *colly.Collector
 	tiprankDividendService   *tiprank.Service
 	log                      logger.ContextLog
-	errorTickers             []string
-	scrapedTickers           []string
+	conf                     *config.ScraperConfig
+	recorder                 *FixtureStore
+	replayer                 *FixtureStore
+	retryPolicy              *RetryPolicy
+	limiter                  *AdaptiveRateLimiter
+	proxyPool                *ProxyPool
+	timer                    *RequestTimer
+	report                   *RunReport
+	taskHandler              TaskHandler
+	dividendHandler          DividendHandler
+	ctx                      context.Context
+	sendCtx                  context.Context // done once the deadline margin is reached, requests stop waiting for the rate limiter
+	cancelSend               context.CancelFunc
+	deadlineMargin           time.Duration
+	stopped                  int32
+	pendingRetries           int32 // retries scheduled but not sent yet, accessed atomically
+	retries                  sync.WaitGroup
+	dryRun                   bool
+	force                    bool
+	configOnce               sync.Once
+}
+
+// TaskHandler is called once a (country, date) task is done, err is nil if the task succeeded
+type TaskHandler func(ctx context.Context, task *entities.ScrapeTask, err error)
+
+// DividendHandler is called with all dividends of a response, it is called concurrently. Results follow the order
+// of the dividends, when err is set the dividends without result failed
+type DividendHandler func(ctx context.Context, tiprankDividends []*entities.TipRankDividend, country string) ([]entities.WriteResult, error)
+
+// errRunStopped is returned by the transport for requests which are not sent because the run has to stop
+var errRunStopped = errors.New("run stopped before the request was sent")
+
+// FailedRequest describes a (country, date) or (country, ticker) request that failed after all retries
+type FailedRequest struct {
+	Country    string     `json:"country"`
+	Date       string     `json:"date,omitempty"`
+	Ticker     string     `json:"ticker,omitempty"`
+	Proxy      string     `json:"proxy,omitempty"`
+	URL        string     `json:"url"`
+	ErrorClass ErrorClass `json:"errorClass"`
+	Error      string     `json:"error"`
+	Attempts   int        `json:"attempts"`
 }
 
 // NewTipRankDividendScraper create new TipRank dividend scraper
-func NewTipRankDividendScraper(tiprankDividendService *tiprank.Service, log logger.ContextLog) *TipRankDividendScraper {
-	scrapeTipRankDividendJob := newScraperJob()
+func NewTipRankDividendScraper(tiprankDividendService *tiprank.Service, log logger.ContextLog, conf *config.ScraperConfig, transport http.RoundTripper) (*TipRankDividendScraper, error) {
+	var proxyPool *ProxyPool
+	if len(conf.Proxies) > 0 {
+		pool, err := NewProxyPool(conf.Proxies, ProxyStrategy(conf.ProxyStrategy), time.Duration(conf.ProxyCooldownMS)*time.Millisecond, conf.ProxyMaxFailures)
+		if err != nil {
+			return nil, err
+		}
+		proxyPool = pool
+	}
+
+	s := &TipRankDividendScraper{
+		tiprankDividendService: tiprankDividendService,
+		dividendHandler:        tiprankDividendService.AddTipRankDividends,
+		log:                    log,
+		conf:                   conf,
+		retryPolicy: &RetryPolicy{
+			MaxRetries: conf.MaxRetries,
+			BaseDelay:  time.Duration(conf.RetryBaseDelayMS) * time.Millisecond,
+			MaxDelay:   time.Duration(conf.RetryMaxDelayMS) * time.Millisecond,
+		},
+		limiter: NewAdaptiveRateLimiter(
+			conf.RequestsPerSecond,
+			conf.MinRequestsPerSecond,
+			conf.MaxRequestsPerSecond,
+			conf.Burst,
+			time.Duration(conf.LatencyThresholdMS)*time.Millisecond,
+			conf.MaxRequestsPerRun,
+		),
+		proxyPool:      proxyPool,
+		timer:          NewRequestTimer(),
+		report:         NewRunReport(runid.New()),
+		ctx:            context.Background(),
+		sendCtx:        context.Background(),
+		deadlineMargin: time.Duration(conf.DeadlineMarginMS) * time.Millisecond,
+	}
+
+	scrapeTipRankDividendJob, err := newScraperJob(conf, transport, proxyPool, s.timer, s.waitToSend)
+	if err != nil {
+		return nil, err
+	}
+	s.ScrapeTipRankDividendJob = scrapeTipRankDividendJob
+
+	scrapeTipRankHistoryJob, err := newScraperJob(conf, transport, proxyPool, s.timer, s.waitToSend)
+	if err != nil {
+		return nil, err
+	}
+	s.ScrapeTipRankHistoryJob = scrapeTipRankHistoryJob
+
+	if conf.RecordDir != "" {
+		s.recorder = NewFixtureStore(conf.RecordDir)
+	}
 
-	return &TipRankDividendScraper{
-		ScrapeTipRankDividendJob: scrapeTipRankDividendJob,
-		tiprankDividendService:   tiprankDividendService,
-		log:                      log,
+	if conf.ReplayDir != "" {
+		s.replayer = NewFixtureStore(conf.ReplayDir)
 	}
+
+	return s, nil
 }
 
 // newScraperJob creates a new colly collector with some custom configs
-func newScraperJob() *colly.Collector {
+func newScraperJob(conf *config.ScraperConfig, transport http.RoundTripper, proxyPool *ProxyPool, timer *RequestTimer, pace PaceFunc) (*colly.Collector, error) {
+	allowedDomains, err := conf.GetAllowedDomains()
+	if err != nil {
+		return nil, err
+	}
+
 	c := colly.NewCollector(
-		colly.AllowedDomains(config.AllowDomain),
+		colly.AllowedDomains(allowedDomains...),
 		colly.Async(true),
 	)
 
-	// Overrides the default timeout (10 seconds) for this collector
-	c.SetRequestTimeout(30 * time.Second)
+	// Replace the default http transport, e.g. to run against a local fake server or a caching proxy
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	// Rotate requests over proxies, the proxy is set on a copy of the http transport
+	if proxyPool != nil {
+		httpTransport, ok := transport.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("proxies require an http transport, got %T", transport)
+		}
+
+		httpTransport = httpTransport.Clone()
+		httpTransport.Proxy = proxyPool.ProxyFunc
+		transport = httpTransport
+	}
+
+	// Wait for the rate limiter once the request holds a parallelism slot, then record when it is sent to measure
+	// its latency without the time colly queued it. The timeout of 30 seconds starts once the request is paced
+	c.WithTransport(newPacedTransport(timer.Wrap(transport), pace, 30*time.Second))
+
+	// Removes the default timeout (10 seconds) of the collector, it would include the time waiting for the rate limiter
+	c.SetRequestTimeout(0)
+
+	// Limit the number of threads started by colly when visiting links which domains' matches the domain glob,
+	// the pace of requests is set by the adaptive rate limiter
+	parallelism := conf.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
 
-	// Limit the number of threads started by colly to two
-	// when visiting links which domains' matches "*httpbin.*" glob
 	c.Limit(&colly.LimitRule{
-		DomainGlob:  config.DomainGlob,
-		Parallelism: 2,
-		RandomDelay: 10 * time.Second,
+		DomainGlob:  conf.GetDomainGlob(),
+		Parallelism: parallelism,
+		RandomDelay: time.Duration(conf.RandomDelayMS) * time.Millisecond,
 	})
 
 	extensions.RandomUserAgent(c)
 	extensions.Referer(c)
 
-	return c
+	return c, nil
 }
 
-// configJobs configs on error handler and on response handler for scaper jobs
+// configJobs configs on request, on error and on response handlers for scaper jobs
 func (s *TipRankDividendScraper) configJobs() {
-	s.ScrapeTipRankDividendJob.OnError(s.errorHandler)
-	s.ScrapeTipRankDividendJob.OnResponse(s.processDividendResponse)
+	s.configOnce.Do(func() {
+		s.ScrapeTipRankDividendJob.OnRequest(s.requestHandler)
+		s.ScrapeTipRankDividendJob.OnError(s.errorHandler)
+		s.ScrapeTipRankDividendJob.OnResponse(s.responseHandler)
+		s.ScrapeTipRankDividendJob.OnResponse(s.processDividendResponse)
+
+		s.ScrapeTipRankHistoryJob.OnRequest(s.requestHandler)
+		s.ScrapeTipRankHistoryJob.OnError(s.errorHandler)
+		s.ScrapeTipRankHistoryJob.OnResponse(s.responseHandler)
+		s.ScrapeTipRankHistoryJob.OnResponse(s.processHistoryResponse)
+	})
 }
 
-// StartSingleDayJob start job
-func (s *TipRankDividendScraper) StartSingleDayJob() {
-	ctx := context.Background()
+// StartRangeJob scrapes TipRank dividends of all dates and countries covered by a given job spec
+func (s *TipRankDividendScraper) StartRangeJob(ctx context.Context, spec JobSpec) error {
+	return s.startRangeJob(ctx, spec, nil)
+}
+
+// StartSourceJob scrapes TipRank dividends of all dates and countries covered by a given job spec and hands them
+// to a given handler instead of adding them through the service. Responses of the job are neither skipped nor
+// recorded as processed since the handler decides what is saved
+func (s *TipRankDividendScraper) StartSourceJob(ctx context.Context, spec JobSpec, sourceHandler DividendHandler) error {
+	return s.startRangeJob(ctx, spec, sourceHandler)
+}
+
+// startRangeJob scrapes TipRank dividends of a job spec, dividends go to the source handler unless it is nil
+func (s *TipRankDividendScraper) startRangeJob(ctx context.Context, spec JobSpec, sourceHandler DividendHandler) error {
+	now := time.Now()
+
+	if err := spec.Validate(now); err != nil {
+		s.log.Error(ctx, "invalid job spec", "error", err)
+		return err
+	}
+
+	return s.startTasksJob(ctx, spec.GetTasks(now), sourceHandler)
+}
+
+// StartTasksJob scrapes TipRank dividends of given (country, date) tasks. When the deadline of the context
+// gets close, it stops sending new requests and the tasks left are reported in the continuation token
+func (s *TipRankDividendScraper) StartTasksJob(ctx context.Context, tasks []*entities.ScrapeTask) error {
+	return s.startTasksJob(ctx, tasks, nil)
+}
+
+// startTasksJob scrapes TipRank dividends of given tasks, the source handler is carried by the context of each
+// request so that jobs never share it
+func (s *TipRankDividendScraper) startTasksJob(ctx context.Context, tasks []*entities.ScrapeTask, sourceHandler DividendHandler) error {
+	ctx = s.setContext(ctx)
+
+	if s.replayer != nil {
+		s.replayJob(ctx, tasks, sourceHandler)
+		return nil
+	}
 
 	s.configJobs()
 
-	for _, countryCode := range consts.TipRankCountries {
-		reqContext := colly.NewContext()
-		reqContext.Put("country", countryCode)
+	for _, task := range tasks {
+		if s.shouldStop() {
+			s.report.AddRemainingTask(task)
+			continue
+		}
 
-		date := time.Now()
+		date, err := time.Parse("2006-01-02", task.Date)
+		if err != nil {
+			s.log.Error(ctx, "invalid task date", "error", err, "country", task.Country, "date", task.Date)
+			s.completeTask(ctx, task, err)
+			continue
+		}
 
-		url := config.GetDividendStockByDateURL(countryCode, date)
+		reqContext := colly.NewContext()
+		reqContext.Put("country", task.Country)
+		reqContext.Put("date", task.Date)
+		if sourceHandler != nil {
+			reqContext.Put("sourceHandler", sourceHandler)
+		}
+
+		url := s.conf.GetDividendStockByDateURL(task.Country, date)
 
-		s.log.Info(ctx, "scraping TipRank dividend", "country", countryCode, "date", date.Format("2006-01-02"), "url", url)
+		s.log.Info(ctx, "scraping TipRank dividend", "country", task.Country, "date", task.Date, "url", url)
 
 		if err := s.ScrapeTipRankDividendJob.Request("GET", url, nil, reqContext, nil); err != nil {
-			s.log.Error(ctx, "scrape TipRank dividend failed", "error", err, "country", countryCode, "date", date.Format("2006-01-02"))
+			s.log.Error(ctx, "scrape TipRank dividend failed", "error", err, "country", task.Country, "date", task.Date)
+			s.completeTask(ctx, task, err)
 		}
 	}
 
-	s.ScrapeTipRankDividendJob.Wait()
+	s.waitJob(s.ScrapeTipRankDividendJob)
+
+	return nil
 }
 
-// StartPreviousWeekJob start job
-func (s *TipRankDividendScraper) StartPreviousWeekJob() {
-	ctx := context.Background()
+// StartHistoryJob scrapes TipRank dividend history of given tickers of a country. When the deadline of the context
+// gets close, it stops sending new requests and the tickers left are reported
+func (s *TipRankDividendScraper) StartHistoryJob(ctx context.Context, tickers []string, countryCode string) error {
+	ctx = s.setContext(ctx)
+
+	market, err := config.GetMarket(countryCode)
+	if err != nil {
+		s.log.Error(ctx, "invalid country", "error", err, "country", countryCode)
+		return err
+	}
+
+	if s.replayer != nil {
+		s.replayHistoryJob(ctx, tickers, countryCode)
+		return nil
+	}
 
 	s.configJobs()
 
-	for _, countryCode := range consts.TipRankCountries {
+	for _, ticker := range tickers {
+		if s.shouldStop() {
+			s.report.AddRemainingTicker(ticker)
+			continue
+		}
+
+		if valid, err := market.IsValidTicker(ticker); err == nil && !valid {
+			s.log.Warn(ctx, "ticker does not match market", "ticker", ticker, "country", countryCode)
+		}
+
 		reqContext := colly.NewContext()
 		reqContext.Put("country", countryCode)
+		reqContext.Put("ticker", ticker)
 
-		for i := 0; i <= 7; i++ {
-			date := time.Now().AddDate(0, 0, -i)
-
-			url := config.GetDividendStockByDateURL(countryCode, date)
+		url := s.conf.GetDividendHistoryByTickerURL(ticker)
 
-			s.log.Info(ctx, "scraping TipRank dividend", "country", countryCode, "date", date.Format("2006-01-02"), "url", url)
+		s.log.Info(ctx, "scraping TipRank dividend history", "country", countryCode, "ticker", ticker, "url", url)
 
-			if err := s.ScrapeTipRankDividendJob.Request("GET", url, nil, reqContext, nil); err != nil {
-				s.log.Error(ctx, "scrape TipRank dividend failed", "error", err, "country", countryCode, "date", date.Format("2006-01-02"))
-			}
+		if err := s.ScrapeTipRankHistoryJob.Request("GET", url, nil, reqContext, nil); err != nil {
+			s.log.Error(ctx, "scrape TipRank dividend history failed", "error", err, "country", countryCode, "ticker", ticker)
 		}
 	}
 
-	s.ScrapeTipRankDividendJob.Wait()
+	s.waitJob(s.ScrapeTipRankHistoryJob)
+
+	return nil
 }
 
-// StartNextWeekJob start job
-func (s *TipRankDividendScraper) StartNextWeekJob() {
-	ctx := context.Background()
+// setContext sets context of the run. The run id carried by the context becomes the run id of the report,
+// a context without run id gets the generated run id of the report
+func (s *TipRankDividendScraper) setContext(ctx context.Context) context.Context {
+	if runID := runid.FromContext(ctx); runID != "" {
+		s.report.RunID = runID
+	} else {
+		ctx = runid.NewContext(ctx, s.report.RunID)
+	}
 
-	s.configJobs()
+	if s.cancelSend != nil {
+		s.cancelSend()
+	}
 
-	for _, countryCode := range consts.TipRankCountries {
-		reqContext := colly.NewContext()
-		reqContext.Put("country", countryCode)
+	s.ctx = ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		s.sendCtx, s.cancelSend = context.WithDeadline(ctx, deadline.Add(-s.deadlineMargin))
+	} else {
+		s.sendCtx, s.cancelSend = context.WithCancel(ctx)
+	}
+
+	return ctx
+}
 
-		for i := 0; i <= 7; i++ {
-			date := time.Now().AddDate(0, 0, i)
+// shouldStop checks whether the run is close to the deadline of its context, once true it stays true
+func (s *TipRankDividendScraper) shouldStop() bool {
+	return !s.hasTimeFor(0)
+}
 
-			url := config.GetDividendStockByDateURL(countryCode, date)
+// hasTimeFor checks whether a given duration can still elapse before the run has to stop
+func (s *TipRankDividendScraper) hasTimeFor(d time.Duration) bool {
+	if atomic.LoadInt32(&s.stopped) == 1 {
+		return false
+	}
 
-			s.log.Info(ctx, "scraping TipRank dividend", "country", countryCode, "date", date.Format("2006-01-02"), "url", url)
+	deadline, ok := s.ctx.Deadline()
+	if !ok || time.Until(deadline)-s.deadlineMargin > d {
+		return true
+	}
 
-			if err := s.ScrapeTipRankDividendJob.Request("GET", url, nil, reqContext, nil); err != nil {
-				s.log.Error(ctx, "scrape TipRank dividend failed", "error", err, "country", countryCode, "date", date.Format("2006-01-02"))
-			}
-		}
+	if d == 0 && atomic.CompareAndSwapInt32(&s.stopped, 0, 1) {
+		s.log.Warn(s.ctx, "deadline is close, stop sending requests", "deadline", deadline.Format(time.RFC3339))
 	}
 
-	s.ScrapeTipRankDividendJob.Wait()
+	return false
 }
 
-// StartPreviousYearJob start job
-func (s *TipRankDividendScraper) StartPreviousYearJob() {
-	ctx := context.Background()
+// SetDryRun sets whether scraped dividends are only parsed and counted without being saved
+func (s *TipRankDividendScraper) SetDryRun(dryRun bool) {
+	s.dryRun = dryRun
+	s.report.DryRun = dryRun
+}
 
-	s.configJobs()
+// SetForce sets whether responses are processed even when identical to the last processed response
+func (s *TipRankDividendScraper) SetForce(force bool) {
+	s.force = force
+}
 
-	for _, countryCode := range consts.TipRankCountries {
-		reqContext := colly.NewContext()
-		reqContext.Put("country", countryCode)
+// SetTaskHandler sets handler called once each task is done, e.g. to checkpoint backfill progress
+func (s *TipRankDividendScraper) SetTaskHandler(taskHandler TaskHandler) {
+	s.taskHandler = taskHandler
+}
 
-		for i := 0; i <= 365; i++ {
-			date := time.Now().AddDate(0, 0, -i)
+// StartSingleDayJob start job
+func (s *TipRankDividendScraper) StartSingleDayJob(ctx context.Context) error {
+	return s.startPresetJob(ctx, RelativeDate(0), RelativeDate(0))
+}
 
-			url := config.GetDividendStockByDateURL(countryCode, date)
+// StartPreviousWeekJob start job
+func (s *TipRankDividendScraper) StartPreviousWeekJob(ctx context.Context) error {
+	return s.startPresetJob(ctx, RelativeDate(-7), RelativeDate(0))
+}
 
-			s.log.Info(ctx, "scraping TipRank dividend", "country", countryCode, "date", date.Format("2006-01-02"), "url", url)
+// StartNextWeekJob start job
+func (s *TipRankDividendScraper) StartNextWeekJob(ctx context.Context) error {
+	return s.startPresetJob(ctx, RelativeDate(0), RelativeDate(7))
+}
 
-			if err := s.ScrapeTipRankDividendJob.Request("GET", url, nil, reqContext, nil); err != nil {
-				s.log.Error(ctx, "scrape TipRank dividend failed", "error", err, "country", countryCode, "date", date.Format("2006-01-02"))
+// StartPreviousYearJob start job
+func (s *TipRankDividendScraper) StartPreviousYearJob(ctx context.Context) error {
+	return s.startPresetJob(ctx, RelativeDate(-365), RelativeDate(0))
+}
+
+// StartDailyJob start job
+func (s *TipRankDividendScraper) StartDailyJob(ctx context.Context) error {
+	// scrape dividend stock of next week
+	return s.startPresetJob(ctx, RelativeDate(7), RelativeDate(7))
+}
+
+// replayJob processes recorded TipRank responses instead of requesting TipRank, tasks left
+// when the deadline gets close are reported like the ones of a scraping job
+func (s *TipRankDividendScraper) replayJob(ctx context.Context, tasks []*entities.ScrapeTask, sourceHandler DividendHandler) {
+	for _, task := range tasks {
+		if s.shouldStop() {
+			s.report.AddRemainingTask(task)
+			continue
+		}
+
+		date, err := time.Parse("2006-01-02", task.Date)
+		if err != nil {
+			s.log.Error(ctx, "invalid task date", "error", err, "country", task.Country, "date", task.Date)
+			s.completeTask(ctx, task, err)
+			continue
+		}
+
+		body, err := s.replayer.Load(task.Country, date)
+		if err != nil {
+			if os.IsNotExist(err) {
+				s.log.Info(ctx, "recorded TipRank dividend not found", "country", task.Country, "date", task.Date)
+			} else {
+				s.log.Error(ctx, "load recorded TipRank dividend failed", "error", err, "country", task.Country, "date", task.Date)
 			}
+
+			s.completeTask(ctx, task, err)
+			continue
 		}
-	}
 
-	s.ScrapeTipRankDividendJob.Wait()
+		// create child correlation id of the run for processing the response
+		id, _ := uuid.NewRandom()
+		responseCtx := corid.NewContext(ctx, id)
+
+		s.log.Info(responseCtx, "replaying TipRank dividend", "runId", s.report.RunID, "country", task.Country, "date", task.Date)
+
+		err = s.processDividends(responseCtx, task.Country, task.Date, "", body, sourceHandler)
+		s.completeTask(responseCtx, task, err)
+	}
 }
 
-// StartDailyJob start job
-func (s *TipRankDividendScraper) StartDailyJob() {
-	ctx := context.Background()
+// replayHistoryJob processes recorded TipRank dividend history responses instead of requesting TipRank
+func (s *TipRankDividendScraper) replayHistoryJob(ctx context.Context, tickers []string, countryCode string) {
+	for _, ticker := range tickers {
+		if s.shouldStop() {
+			s.report.AddRemainingTicker(ticker)
+			continue
+		}
 
-	s.configJobs()
+		body, err := s.replayer.LoadHistory(countryCode, ticker)
+		if err != nil {
+			if os.IsNotExist(err) {
+				s.log.Info(ctx, "recorded TipRank dividend history not found", "country", countryCode, "ticker", ticker)
+			} else {
+				s.log.Error(ctx, "load recorded TipRank dividend history failed", "error", err, "country", countryCode, "ticker", ticker)
+			}
 
-	for _, countryCode := range consts.TipRankCountries {
-		reqContext := colly.NewContext()
-		reqContext.Put("country", countryCode)
+			continue
+		}
 
-		// scrape dividend stock of next week
-		date := time.Now().AddDate(0, 0, 7)
+		// create child correlation id of the run for processing the response
+		id, _ := uuid.NewRandom()
+		responseCtx := corid.NewContext(ctx, id)
 
-		url := config.GetDividendStockByDateURL(countryCode, date)
+		s.log.Info(responseCtx, "replaying TipRank dividend history", "runId", s.report.RunID, "country", countryCode, "ticker", ticker)
 
-		s.log.Info(ctx, "scraping TipRank dividend", "country", countryCode, "date", date.Format("2006-01-02"), "url", url)
+		s.processHistory(responseCtx, countryCode, ticker, "", body)
+	}
+}
 
-		if err := s.ScrapeTipRankDividendJob.Request("GET", url, nil, reqContext, nil); err != nil {
-			s.log.Error(ctx, "scrape TipRank dividend failed", "error", err, "country", countryCode, "date", date.Format("2006-01-02"))
-		}
+// completeTask calls task handler if any
+func (s *TipRankDividendScraper) completeTask(ctx context.Context, task *entities.ScrapeTask, err error) {
+	if s.taskHandler != nil {
+		s.taskHandler(ctx, task, err)
 	}
+}
 
-	s.ScrapeTipRankDividendJob.Wait()
+// startPresetJob starts range job of all TipRank countries
+func (s *TipRankDividendScraper) startPresetJob(ctx context.Context, from JobDate, to JobDate) error {
+	spec := JobSpec{
+		From: from,
+		To:   to,
+	}
+
+	return s.StartRangeJob(ctx, spec)
 }
 
 ///////////////////////////////////////////////////////////
 // Scraper Handler
 ///////////////////////////////////////////////////////////
 
-// errorHandler generic error handler for all scaper jobs
+// requestHandler aborts requests queued once the run has to stop and assigns a proxy to the others. Colly calls it
+// when a request is queued, the rate limiter is waited for by the transport once the request holds a parallelism slot
+func (s *TipRankDividendScraper) requestHandler(r *colly.Request) {
+	if s.shouldStop() {
+		r.Abort()
+		s.addRemaining(r.Ctx)
+		return
+	}
+
+	if s.proxyPool != nil {
+		proxyName := s.proxyPool.Assign(r.URL.String(), r.Ctx.Get("country"))
+		r.Ctx.Put("proxy", proxyName)
+		s.log.Info(s.ctx, "requesting url through proxy", "url", r.URL, "proxy", proxyName)
+	}
+}
+
+// waitToSend waits for the rate limiter before a request is sent, it fails when the run has to stop or has no
+// request budget left. Waiting stops once the deadline margin is reached so jobs return in time to report
+func (s *TipRankDividendScraper) waitToSend(req *http.Request) error {
+	if s.shouldStop() {
+		return errRunStopped
+	}
+
+	if err := s.limiter.Wait(s.sendCtx); err != nil {
+		if err != ErrBudgetExhausted {
+			return errRunStopped
+		}
+
+		if s.report.SetBudgetExhausted() {
+			s.log.Warn(s.ctx, "request budget exhausted, stop sending requests", "maxRequestsPerRun", s.conf.MaxRequestsPerRun)
+		}
+
+		return err
+	}
+
+	// the deadline may have come closer while waiting
+	if s.shouldStop() {
+		return errRunStopped
+	}
+
+	return nil
+}
+
+// countRequest counts a request sent to TipRank, including retries
+func (s *TipRankDividendScraper) countRequest(r *colly.Request) {
+	if proxyName := r.Ctx.Get("proxy"); s.proxyPool != nil && proxyName != "" {
+		s.report.AddProxyRequest(proxyName)
+	}
+
+	s.report.AddRequest(r.Ctx.Get("country"), r.Ctx.Get("date"))
+}
+
+// responseHandler speeds the rate limiter up or down depending on the response latency
+func (s *TipRankDividendScraper) responseHandler(r *colly.Response) {
+	s.countRequest(r.Request)
+	s.limiter.OnSuccess(s.timer.Elapsed(r.Request.URL.String()))
+
+	if proxyName := r.Request.Ctx.Get("proxy"); s.proxyPool != nil && proxyName != "" {
+		s.proxyPool.Release(r.Request.URL.String())
+		s.proxyPool.OnSuccess(proxyName)
+	}
+}
+
+// errorHandler generic error handler for all scaper jobs, it retries transient failures with backoff
 func (s *TipRankDividendScraper) errorHandler(r *colly.Response, err error) {
-	ctx := context.Background()
-	s.log.Error(ctx, "failed to request url", "url", r.Request.URL, "error", err)
+	ctx := s.ctx
+
+	// the request was not sent, leave it to the next run
+	if errors.Is(err, errRunStopped) || errors.Is(err, ErrBudgetExhausted) {
+		if s.proxyPool != nil && r.Request.Ctx.Get("proxy") != "" {
+			s.proxyPool.Release(r.Request.URL.String())
+		}
+
+		s.addRemaining(r.Request.Ctx)
+		return
+	}
+
+	s.countRequest(r.Request)
+
+	errorClass := classifyError(r, err)
+	attempt := getAttempt(r.Request.Ctx)
+	s.timer.Elapsed(r.Request.URL.String())
+
+	switch errorClass {
+	case ErrorClassRateLimited:
+		s.limiter.OnThrottled(getRetryAfter(r))
+		s.log.Warn(ctx, "throttled by TipRank, slow down", "url", r.Request.URL, "rate", s.limiter.GetRate())
+	case ErrorClassServer, ErrorClassTimeout:
+		s.limiter.OnFailure()
+	}
+
+	s.handleProxyError(r, err, errorClass)
+
+	if errorClass.IsTransient() && attempt < s.retryPolicy.MaxRetries {
+		delay := s.retryPolicy.Backoff(attempt, getRetryAfter(r))
+
+		// leave the task to the next run rather than retrying past the deadline
+		if !s.hasTimeFor(delay) {
+			s.log.Warn(ctx, "no time left to retry url", "url", r.Request.URL, "error", err, "errorClass", errorClass)
+			s.addRemaining(r.Request.Ctx)
+			return
+		}
+
+		s.log.Warn(ctx, "retrying url", "url", r.Request.URL, "error", err, "errorClass", errorClass, "attempt", attempt+1, "delay", delay.String())
+
+		s.scheduleRetry(r, err, errorClass, delay)
+		return
+	}
+
+	s.failRequest(r, err, errorClass)
 }
 
+// scheduleRetry retries a request once a delay has elapsed without holding up the worker of the collector,
+// jobs wait for scheduled retries before they return
+func (s *TipRankDividendScraper) scheduleRetry(r *colly.Response, err error, errorClass ErrorClass, delay time.Duration) {
+	atomic.AddInt32(&s.pendingRetries, 1)
+	s.retries.Add(1)
+
+	time.AfterFunc(delay, func() {
+		defer s.retries.Done()
+		defer atomic.AddInt32(&s.pendingRetries, -1)
+
+		attempt := getAttempt(r.Request.Ctx)
+		r.Request.Ctx.Put("attempt", attempt+1)
+
+		if retryErr := r.Request.Retry(); retryErr != nil {
+			s.log.Error(s.ctx, "retry url failed", "url", r.Request.URL, "error", retryErr)
+			r.Request.Ctx.Put("attempt", attempt)
+			s.failRequest(r, err, errorClass)
+		}
+	})
+}
+
+// waitJob waits for all requests of a job, including retries scheduled by failed requests
+func (s *TipRankDividendScraper) waitJob(c *colly.Collector) {
+	for {
+		c.Wait()
+
+		// callbacks are done so no retry can be scheduled until the pending ones are sent
+		if atomic.LoadInt32(&s.pendingRetries) == 0 {
+			return
+		}
+
+		s.retries.Wait()
+	}
+}
+
+// failRequest reports a request which failed after all retries
+func (s *TipRankDividendScraper) failRequest(r *colly.Response, err error, errorClass ErrorClass) {
+	ctx := s.ctx
+	attempt := getAttempt(r.Request.Ctx)
+
+	s.log.Error(ctx, "failed to request url", "url", r.Request.URL, "error", err, "errorClass", errorClass, "attempts", attempt+1)
+
+	s.report.AddHTTPFailure(FailedRequest{
+		Country:    r.Request.Ctx.Get("country"),
+		Date:       r.Request.Ctx.Get("date"),
+		Ticker:     r.Request.Ctx.Get("ticker"),
+		Proxy:      r.Request.Ctx.Get("proxy"),
+		URL:        r.Request.URL.String(),
+		ErrorClass: errorClass,
+		Error:      err.Error(),
+		Attempts:   attempt + 1,
+	})
+
+	if r.Request.Ctx.Get("ticker") == "" {
+		s.completeTask(ctx, getTask(r.Request.Ctx), err)
+	}
+}
+
+// handleProxyError counts failures of the proxy of a request, TipRank server and client errors mean the proxy works
+func (s *TipRankDividendScraper) handleProxyError(r *colly.Response, err error, errorClass ErrorClass) {
+	proxyName := r.Request.Ctx.Get("proxy")
+	if s.proxyPool == nil || proxyName == "" {
+		return
+	}
+
+	s.proxyPool.Release(r.Request.URL.String())
+
+	if errorClass != ErrorClassNetwork && errorClass != ErrorClassTimeout && errorClass != ErrorClassRateLimited {
+		s.proxyPool.OnSuccess(proxyName)
+		return
+	}
+
+	s.log.Warn(s.ctx, "proxy request failed", "proxy", proxyName, "url", r.Request.URL, "error", err, "errorClass", errorClass)
+	s.report.AddProxyFailure(proxyName)
+
+	if s.proxyPool.OnFailure(proxyName) {
+		s.log.Warn(s.ctx, "proxy benched", "proxy", proxyName, "cooldownMs", s.conf.ProxyCooldownMS)
+		s.report.AddBenchedProxy(proxyName)
+	}
+}
+
+// processDividendResponse records and processes TipRank dividends of a response
 func (s *TipRankDividendScraper) processDividendResponse(r *colly.Response) {
-	// create correlation if for processing fund list
+	// create child correlation id of the run for processing the response
+	id, _ := uuid.NewRandom()
+	ctx := corid.NewContext(s.ctx, id)
+
+	countryCode := r.Request.Ctx.Get("country")
+	date := r.Request.Ctx.Get("date")
+	s.log.Info(ctx, "processDividendResponse", "runId", s.report.RunID, "country", countryCode, "date", date)
+
+	if s.recorder != nil {
+		if err := s.recordDividendResponse(countryCode, date, r.Body); err != nil {
+			s.log.Error(ctx, "record response failed", "error", err, "country", countryCode, "date", date)
+		}
+	}
+
+	sourceHandler, _ := r.Request.Ctx.GetAny("sourceHandler").(DividendHandler)
+
+	err := s.processDividends(ctx, countryCode, date, r.Request.URL.String(), r.Body, sourceHandler)
+	s.completeTask(ctx, getTask(r.Request.Ctx), err)
+}
+
+// processHistoryResponse processes TipRank dividend history of a ticker
+func (s *TipRankDividendScraper) processHistoryResponse(r *colly.Response) {
+	// create child correlation id of the run for processing the response
 	id, _ := uuid.NewRandom()
-	ctx := corid.NewContext(context.Background(), id)
+	ctx := corid.NewContext(s.ctx, id)
 
 	countryCode := r.Request.Ctx.Get("country")
-	s.log.Info(ctx, "processDividendResponse")
+	ticker := r.Request.Ctx.Get("ticker")
+	s.log.Info(ctx, "processHistoryResponse", "runId", s.report.RunID, "country", countryCode, "ticker", ticker)
+
+	if s.recorder != nil {
+		if err := s.recorder.SaveHistory(countryCode, ticker, r.Body); err != nil {
+			s.log.Error(ctx, "record response failed", "error", err, "country", countryCode, "ticker", ticker)
+		}
+	}
+
+	s.processHistory(ctx, countryCode, ticker, r.Request.URL.String(), r.Body)
+}
+
+// processHistory adds TipRank dividend history of a ticker from a raw response body
+func (s *TipRankDividendScraper) processHistory(ctx context.Context, countryCode string, ticker string, url string, body []byte) {
+	// strictly decode response data
+	tiprankDividends, drift, err := decodeTipRankDividends(body, tiprankDividendHistoryFields)
+
+	if drift.HasDrift() {
+		s.log.Warn(ctx, "TipRank response drifted", "country", countryCode, "ticker", ticker, "nonJson", drift.NonJSON, "unknownFields", drift.UnknownFields, "missingFields", drift.MissingFields, "typeMismatches", drift.TypeMismatches)
+		s.report.AddDrift(drift)
+	}
+
+	if err != nil || drift.IsBreaking() {
+		reason := "breaking drift"
+		if err != nil {
+			reason = err.Error()
+		}
 
-	var tiprankDividends []*entities.TipRankDividend
+		s.quarantineResponse(ctx, &entities.QuarantinedResponse{
+			Country: countryCode,
+			Ticker:  ticker,
+			URL:     url,
+			RunID:   s.report.RunID,
+			Reason:  reason,
+			Drift:   drift,
+			Body:    body,
+			Hash:    hash.GetContentHash(body),
+		})
+	}
 
-	// unmarshal response data
-	if err := json.Unmarshal(r.Body, &tiprankDividends); err != nil {
-		s.log.Error(ctx, "unmarshal response failed", "error", err)
+	if err != nil {
+		s.log.Error(ctx, "decode response failed", "error", err, "country", countryCode, "ticker", ticker)
+		s.report.AddHistoryParseFailure(countryCode, ticker, err)
 		return
 	}
 
+	s.report.AddParsed(len(tiprankDividends))
+
+	// the ticker is implied by the request
 	for _, tiprankDividend := range tiprankDividends {
-		if err := s.tiprankDividendService.AddTipRankDividend(ctx, tiprankDividend, countryCode); err != nil {
-			s.log.Error(ctx, "add TipRank dividend failed", "error", err, "ticker", tiprankDividend.Ticker)
-			s.errorTickers = append(s.errorTickers, tiprankDividend.Ticker)
-		} else {
-			s.scrapedTickers = append(s.scrapedTickers, tiprankDividend.Ticker)
+		if tiprankDividend.Ticker == "" {
+			tiprankDividend.Ticker = ticker
 		}
 	}
+
+	if s.dryRun {
+		s.log.Info(ctx, "dry run, skip adding TipRank dividend history", "country", countryCode, "ticker", ticker, "count", len(tiprankDividends))
+		return
+	}
+
+	result, rejected, err := s.tiprankDividendService.AddTipRankDividendHistory(ctx, ticker, tiprankDividends, countryCode)
+	s.report.AddRejected(rejected)
+
+	if err != nil {
+		s.log.Error(ctx, "add TipRank dividend history failed", "error", err, "ticker", ticker)
+		s.report.AddFailedTicker(FailedTicker{
+			Ticker:  ticker,
+			Country: countryCode,
+			Reason:  err.Error(),
+		})
+		return
+	}
+
+	// rejected dividends are already counted
+	if result != entities.WriteResultRejected {
+		s.report.AddWriteResult(result)
+	}
+}
+
+// recordDividendResponse writes raw response body of a given country and date
+func (s *TipRankDividendScraper) recordDividendResponse(countryCode string, dateString string, body []byte) error {
+	date, err := time.Parse("2006-01-02", dateString)
+	if err != nil {
+		return err
+	}
+
+	return s.recorder.Save(countryCode, date, body)
+}
+
+// processDividends adds TipRank dividends of a raw response body, responses whose shape drifted are quarantined
+// and responses identical to the last processed response of the same country and date are skipped. Dividends go
+// to the source handler unless it is nil, responses are then neither skipped nor recorded as processed
+func (s *TipRankDividendScraper) processDividends(ctx context.Context, countryCode string, date string, url string, body []byte, sourceHandler DividendHandler) error {
+	responseHash := hash.GetContentHash(body)
+
+	if !s.force && sourceHandler == nil {
+		unchanged, err := s.tiprankDividendService.IsResponseUnchanged(ctx, countryCode, date, responseHash)
+		if err != nil {
+			s.log.Warn(ctx, "check response hash failed, process response anyway", "error", err, "country", countryCode, "date", date)
+		} else if unchanged {
+			s.log.Info(ctx, "response unchanged, skip processing", "country", countryCode, "date", date)
+			s.report.AddSkippedResponse()
+			return nil
+		}
+	}
+
+	// strictly decode response data
+	tiprankDividends, drift, err := decodeTipRankDividends(body, tiprankDividendFields)
+
+	if drift.HasDrift() {
+		s.log.Warn(ctx, "TipRank response drifted", "country", countryCode, "date", date, "nonJson", drift.NonJSON, "unknownFields", drift.UnknownFields, "missingFields", drift.MissingFields, "typeMismatches", drift.TypeMismatches)
+		s.report.AddDrift(drift)
+	}
+
+	if err != nil || drift.IsBreaking() {
+		reason := "breaking drift"
+		if err != nil {
+			reason = err.Error()
+		}
+
+		s.quarantineResponse(ctx, &entities.QuarantinedResponse{
+			Country: countryCode,
+			Date:    date,
+			URL:     url,
+			RunID:   s.report.RunID,
+			Reason:  reason,
+			Drift:   drift,
+			Body:    body,
+			Hash:    responseHash,
+		})
+	}
+
+	if err != nil {
+		s.log.Error(ctx, "decode response failed", "error", err, "country", countryCode, "date", date)
+		s.report.AddParseFailure(countryCode, date, err)
+		return err
+	}
+
+	s.report.AddParsed(len(tiprankDividends))
+
+	if s.dryRun {
+		s.log.Info(ctx, "dry run, skip adding TipRank dividends", "country", countryCode, "date", date, "count", len(tiprankDividends))
+		return nil
+	}
+
+	dividendHandler := s.dividendHandler
+	if sourceHandler != nil {
+		dividendHandler = sourceHandler
+	}
+
+	results, err := dividendHandler(ctx, tiprankDividends, countryCode)
+	if err != nil {
+		s.log.Error(ctx, "add TipRank dividends failed", "error", err, "country", countryCode, "date", date)
+	}
+
+	var failedTickers []string
+	for i, tiprankDividend := range tiprankDividends {
+		if i < len(results) && results[i] != "" {
+			s.report.AddWriteResult(results[i])
+			continue
+		}
+
+		if err == nil {
+			continue
+		}
+
+		s.report.AddFailedTicker(FailedTicker{
+			Ticker:  tiprankDividend.Ticker,
+			Country: countryCode,
+			Date:    date,
+			Reason:  err.Error(),
+		})
+		failedTickers = append(failedTickers, tiprankDividend.Ticker)
+	}
+
+	if len(failedTickers) > 0 {
+		return fmt.Errorf("add TipRank dividend failed for tickers %v", failedTickers)
+	}
+
+	// keep processing responses with breaking drift until the decoder is fixed
+	if !drift.IsBreaking() && sourceHandler == nil {
+		if err := s.tiprankDividendService.SaveResponseHash(ctx, countryCode, date, responseHash); err != nil {
+			s.log.Error(ctx, "save response hash failed", "error", err, "country", countryCode, "date", date)
+		}
+	}
+
+	return nil
+}
+
+// quarantineResponse keeps aside a raw response unless it is a dry run
+func (s *TipRankDividendScraper) quarantineResponse(ctx context.Context, quarantinedResponse *entities.QuarantinedResponse) {
+	if s.dryRun {
+		s.log.Info(ctx, "dry run, skip quarantining response", "country", quarantinedResponse.Country, "date", quarantinedResponse.Date, "ticker", quarantinedResponse.Ticker)
+		return
+	}
+
+	if err := s.tiprankDividendService.QuarantineResponse(ctx, quarantinedResponse); err != nil {
+		s.log.Error(ctx, "quarantine response failed", "error", err, "country", quarantinedResponse.Country, "date", quarantinedResponse.Date, "ticker", quarantinedResponse.Ticker)
+		return
+	}
+
+	s.report.AddQuarantined()
+}
+
+// addRemaining records the ticker or the task of a request context as left for the next run
+func (s *TipRankDividendScraper) addRemaining(reqContext *colly.Context) {
+	if ticker := reqContext.Get("ticker"); ticker != "" {
+		s.report.AddRemainingTicker(ticker)
+		return
+	}
+
+	s.report.AddRemainingTask(getTask(reqContext))
+}
+
+// getTask gets task of a request context
+func getTask(reqContext *colly.Context) *entities.ScrapeTask {
+	return &entities.ScrapeTask{
+		Country: reqContext.Get("country"),
+		Date:    reqContext.Get("date"),
+	}
 }
 
 // Close scraper
-func (s *TipRankDividendScraper) Close() []string {
-	s.log.Info(context.Background(), "DONE - SCRAPING TIPRANK DIVIDENDS", "errorTickers", s.errorTickers)
-	return s.scrapedTickers
+func (s *TipRankDividendScraper) Close() *RunReport {
+	if s.cancelSend != nil {
+		s.cancelSend()
+	}
+
+	s.report.SetVersionConflicts(s.tiprankDividendService.GetVersionConflicts())
+
+	if err := s.report.Finish(); err != nil {
+		s.log.Error(s.ctx, "create continuation token failed", "error", err)
+	}
+
+	s.log.Info(s.ctx, "DONE - SCRAPING TIPRANK DIVIDENDS", "runId", s.report.RunID, "partial", s.report.Partial, "remaining", s.report.Remaining, "skippedResponses", s.report.SkippedResponses, "remainingTickers", s.report.RemainingTickers, "inserted", s.report.Inserted, "updated", s.report.Updated, "unchanged", s.report.Unchanged, "rejected", s.report.Rejected, "versionConflicts", s.report.VersionConflicts, "failedTickers", s.report.FailedTickers, "httpFailures", s.report.HTTPFailures, "parseFailures", s.report.ParseFailures)
+	return s.report
 }
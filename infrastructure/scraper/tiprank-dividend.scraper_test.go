@@ -0,0 +1,319 @@
+package scraper
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gocolly/colly"
+	logger "github.com/lenoobz/aws-lambda-logger"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/usecase/tiprank"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/hash"
+)
+
+// fakeTipRankDividendRepo keeps what the scraper writes in memory, every dividend write is an insert
+type fakeTipRankDividendRepo struct {
+	hashes      map[string]string
+	written     []*entities.TipRankDividendWrite
+	rejected    []*entities.RejectedDividend
+	quarantined []*entities.QuarantinedResponse
+}
+
+func newFakeTipRankDividendRepo() *fakeTipRankDividendRepo {
+	return &fakeTipRankDividendRepo{
+		hashes: map[string]string{},
+	}
+}
+
+func (r *fakeTipRankDividendRepo) GetByTicker(ctx context.Context, ticker string) (*entities.DividendStock, error) {
+	return nil, nil
+}
+
+func (r *fakeTipRankDividendRepo) GetBySymbol(ctx context.Context, symbol *entities.Symbol) (*entities.DividendStock, error) {
+	return nil, nil
+}
+
+func (r *fakeTipRankDividendRepo) GetByTickers(ctx context.Context, tickers []string) ([]*entities.DividendStock, error) {
+	return nil, nil
+}
+
+func (r *fakeTipRankDividendRepo) ListByExDateRange(ctx context.Context, from time.Time, to time.Time, exchanges []string) ([]*entities.DividendStock, error) {
+	return nil, nil
+}
+
+func (r *fakeTipRankDividendRepo) ListByPayDateRange(ctx context.Context, from time.Time, to time.Time, exchanges []string) ([]*entities.DividendStock, error) {
+	return nil, nil
+}
+
+func (r *fakeTipRankDividendRepo) ListUpcoming(ctx context.Context, today time.Time, days int, exchanges []string) ([]*entities.DividendStock, error) {
+	return nil, nil
+}
+
+func (r *fakeTipRankDividendRepo) ListAll(ctx context.Context, cursor string, limit int) (*entities.DividendStockPage, error) {
+	return &entities.DividendStockPage{}, nil
+}
+
+func (r *fakeTipRankDividendRepo) GetResponseHash(ctx context.Context, country string, date string) (string, error) {
+	return r.hashes[country+"/"+date], nil
+}
+
+func (r *fakeTipRankDividendRepo) InsertTipRankDividends(ctx context.Context, tiprankDividendWrites []*entities.TipRankDividendWrite) ([]entities.WriteResult, error) {
+	results := make([]entities.WriteResult, len(tiprankDividendWrites))
+	for i := range tiprankDividendWrites {
+		results[i] = entities.WriteResultInserted
+	}
+
+	r.written = append(r.written, tiprankDividendWrites...)
+	return results, nil
+}
+
+func (r *fakeTipRankDividendRepo) InsertTipRankDividendHistory(ctx context.Context, ticker string, symbol *entities.Symbol, tiprankDividends []*entities.TipRankDividend, currency string) (entities.WriteResult, error) {
+	return entities.WriteResultInserted, nil
+}
+
+func (r *fakeTipRankDividendRepo) InsertQuarantinedResponse(ctx context.Context, quarantinedResponse *entities.QuarantinedResponse) error {
+	r.quarantined = append(r.quarantined, quarantinedResponse)
+	return nil
+}
+
+func (r *fakeTipRankDividendRepo) InsertRejectedDividend(ctx context.Context, rejectedDividend *entities.RejectedDividend) error {
+	r.rejected = append(r.rejected, rejectedDividend)
+	return nil
+}
+
+func (r *fakeTipRankDividendRepo) InsertResponseHash(ctx context.Context, country string, date string, hash string) error {
+	r.hashes[country+"/"+date] = hash
+	return nil
+}
+
+func (r *fakeTipRankDividendRepo) GetVersionConflicts() int {
+	return 0
+}
+
+// TestProcessDividendResponseReplay replays recorded TipRank responses of testdata through the response handler
+func TestProcessDividendResponseReplay(t *testing.T) {
+	tests := []struct {
+		name            string
+		date            string
+		unchanged       bool
+		source          bool
+		wantErr         bool
+		wantSymbols     []string
+		wantRejected    int
+		wantQuarantined int
+		wantSkipped     int
+		wantParsed      int
+		wantHashSaved   bool
+	}{
+		{
+			name:          "valid and invalid dividends",
+			date:          "2021-03-01",
+			wantSymbols:   []string{"TSX:RY"},
+			wantRejected:  1,
+			wantParsed:    2,
+			wantHashSaved: true,
+		},
+		{
+			name:            "drifted record is dropped and the response quarantined",
+			date:            "2021-03-02",
+			wantSymbols:     []string{"TSX:TD"},
+			wantQuarantined: 1,
+			wantParsed:      1,
+		},
+		{
+			name:            "anti-bot page",
+			date:            "2021-03-03",
+			wantErr:         true,
+			wantQuarantined: 1,
+		},
+		{
+			name:          "unchanged response is skipped",
+			date:          "2021-03-01",
+			unchanged:     true,
+			wantSkipped:   1,
+			wantHashSaved: true,
+		},
+		{
+			name:         "source job does not save the response hash",
+			date:         "2021-03-01",
+			source:       true,
+			wantSymbols:  []string{"TSX:RY"},
+			wantRejected: 1,
+			wantParsed:   2,
+		},
+		{
+			name:          "source job does not skip an unchanged response",
+			date:          "2021-03-01",
+			unchanged:     true,
+			source:        true,
+			wantSymbols:   []string{"TSX:RY"},
+			wantRejected:  1,
+			wantParsed:    2,
+			wantHashSaved: true,
+		},
+	}
+
+	log, err := logger.NewZapLogger()
+	if err != nil {
+		t.Fatalf("create logger failed: %v", err)
+	}
+
+	fixtures := NewFixtureStore("testdata")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, err := time.Parse("2006-01-02", tt.date)
+			if err != nil {
+				t.Fatalf("invalid fixture date: %v", err)
+			}
+
+			body, err := fixtures.Load("Canada", date)
+			if err != nil {
+				t.Fatalf("load fixture failed: %v", err)
+			}
+
+			repo := newFakeTipRankDividendRepo()
+			if tt.unchanged {
+				repo.hashes["Canada/"+tt.date] = hash.GetContentHash(body)
+			}
+
+			service := tiprank.NewService(repo, log)
+
+			var taskErr error
+			s := &TipRankDividendScraper{
+				tiprankDividendService: service,
+				dividendHandler:        service.AddTipRankDividends,
+				log:                    log,
+				report:                 NewRunReport("test"),
+				ctx:                    context.Background(),
+				taskHandler: func(ctx context.Context, task *entities.ScrapeTask, err error) {
+					taskErr = err
+				},
+			}
+
+			reqContext := colly.NewContext()
+			reqContext.Put("country", "Canada")
+			reqContext.Put("date", tt.date)
+
+			sourceCalls := 0
+			if tt.source {
+				reqContext.Put("sourceHandler", DividendHandler(func(ctx context.Context, tiprankDividends []*entities.TipRankDividend, country string) ([]entities.WriteResult, error) {
+					sourceCalls++
+					return service.AddTipRankDividends(ctx, tiprankDividends, country)
+				}))
+			}
+
+			requestURL, _ := url.Parse("https://www.tipranks.com/api/dividends/getByDate/?name=" + tt.date + "&country=Canada")
+
+			s.processDividendResponse(&colly.Response{
+				StatusCode: 200,
+				Body:       body,
+				Request: &colly.Request{
+					URL: requestURL,
+					Ctx: reqContext,
+				},
+			})
+
+			if (taskErr != nil) != tt.wantErr {
+				t.Errorf("task error = %v, wantErr %v", taskErr, tt.wantErr)
+			}
+
+			var symbols []string
+			for _, write := range repo.written {
+				symbols = append(symbols, write.Symbol.String())
+			}
+
+			if !reflect.DeepEqual(symbols, tt.wantSymbols) {
+				t.Errorf("written symbols = %v, want %v", symbols, tt.wantSymbols)
+			}
+
+			if len(repo.rejected) != tt.wantRejected || s.report.Rejected != tt.wantRejected {
+				t.Errorf("rejected = %d, reported %d, want %d", len(repo.rejected), s.report.Rejected, tt.wantRejected)
+			}
+
+			if len(repo.quarantined) != tt.wantQuarantined || s.report.Quarantined != tt.wantQuarantined {
+				t.Errorf("quarantined = %d, reported %d, want %d", len(repo.quarantined), s.report.Quarantined, tt.wantQuarantined)
+			}
+
+			if s.report.Inserted != len(tt.wantSymbols) {
+				t.Errorf("reported inserted = %d, want %d", s.report.Inserted, len(tt.wantSymbols))
+			}
+
+			if s.report.SkippedResponses != tt.wantSkipped {
+				t.Errorf("reported skipped responses = %d, want %d", s.report.SkippedResponses, tt.wantSkipped)
+			}
+
+			if s.report.Parsed != tt.wantParsed {
+				t.Errorf("reported parsed = %d, want %d", s.report.Parsed, tt.wantParsed)
+			}
+
+			if _, saved := repo.hashes["Canada/"+tt.date]; saved != tt.wantHashSaved {
+				t.Errorf("response hash saved = %v, want %v", saved, tt.wantHashSaved)
+			}
+
+			if tt.source && sourceCalls != 1 {
+				t.Errorf("source handler calls = %d, want 1", sourceCalls)
+			}
+		})
+	}
+}
+
+// TestProcessHistoryQuarantine checks dividend history responses whose shape drifted are quarantined by ticker
+func TestProcessHistoryQuarantine(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		wantQuarantined bool
+	}{
+		{
+			name: "valid history",
+			body: `[{"amount":1.08,"exDate":"2021-03-01T00:00:00"}]`,
+		},
+		{
+			name:            "record of unexpected type",
+			body:            `[{"amount":"1.08","exDate":"2021-03-01T00:00:00"}]`,
+			wantQuarantined: true,
+		},
+		{
+			name:            "anti-bot page",
+			body:            `<html><body>Please verify you are a human</body></html>`,
+			wantQuarantined: true,
+		},
+	}
+
+	log, err := logger.NewZapLogger()
+	if err != nil {
+		t.Fatalf("create logger failed: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeTipRankDividendRepo()
+
+			s := &TipRankDividendScraper{
+				tiprankDividendService: tiprank.NewService(repo, log),
+				log:                    log,
+				report:                 NewRunReport("test"),
+				ctx:                    context.Background(),
+			}
+
+			s.processHistory(context.Background(), "Canada", "TSE:RY", "https://www.tipranks.com/api/dividends/ticker/RY", []byte(tt.body))
+
+			if got := len(repo.quarantined) == 1; got != tt.wantQuarantined || s.report.Quarantined != len(repo.quarantined) {
+				t.Fatalf("quarantined = %d, reported %d, want quarantined %v", len(repo.quarantined), s.report.Quarantined, tt.wantQuarantined)
+			}
+
+			if !tt.wantQuarantined {
+				return
+			}
+
+			quarantined := repo.quarantined[0]
+			if quarantined.Ticker != "TSE:RY" || quarantined.Date != "" || quarantined.Hash != hash.GetContentHash([]byte(tt.body)) {
+				t.Errorf("quarantined ticker = %q, date = %q, hash = %q, want the ticker and the hash of the body", quarantined.Ticker, quarantined.Date, quarantined.Hash)
+			}
+		})
+	}
+}
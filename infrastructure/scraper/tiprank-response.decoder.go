@@ -0,0 +1,157 @@
+package scraper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+)
+
+// jsonKind is the kind of a json value
+type jsonKind string
+
+// json kinds
+const (
+	jsonKindString jsonKind = "string"
+	jsonKindNumber jsonKind = "number"
+	jsonKindBool   jsonKind = "bool"
+	jsonKindNull   jsonKind = "null"
+	jsonKindObject jsonKind = "object"
+	jsonKindArray  jsonKind = "array"
+)
+
+// expectedField describes a field of a TipRank dividend record, it should match json tags of entities.TipRankDividend
+type expectedField struct {
+	kind     jsonKind
+	required bool
+}
+
+// tiprankDividendFields lists fields of a TipRank dividend record
+var tiprankDividendFields = map[string]expectedField{
+	"ticker":  {kind: jsonKindString, required: true},
+	"company": {kind: jsonKindString},
+	"yield":   {kind: jsonKindNumber},
+	"amount":  {kind: jsonKindNumber, required: true},
+	"exDate":  {kind: jsonKindString, required: true},
+	"recDate": {kind: jsonKindString},
+	"payDate": {kind: jsonKindString},
+}
+
+// tiprankDividendHistoryFields lists fields of a record of TipRank dividend history of a ticker,
+// the ticker is implied by the request so it may be missing
+var tiprankDividendHistoryFields = map[string]expectedField{
+	"ticker":  {kind: jsonKindString},
+	"company": {kind: jsonKindString},
+	"yield":   {kind: jsonKindNumber},
+	"amount":  {kind: jsonKindNumber, required: true},
+	"exDate":  {kind: jsonKindString, required: true},
+	"recDate": {kind: jsonKindString},
+	"payDate": {kind: jsonKindString},
+}
+
+// decodeTipRankDividends strictly decodes a TipRank dividend response. Records with a field of an unexpected type
+// are dropped, and the drift report tells how the response differs from the expected shape. It fails only when
+// the whole body is unusable, e.g. an HTML anti-bot page
+func decodeTipRankDividends(body []byte, fields map[string]expectedField) ([]*entities.TipRankDividend, *entities.ResponseDrift, error) {
+	drift := entities.NewResponseDrift()
+
+	trimmedBody := bytes.TrimSpace(body)
+	if len(trimmedBody) == 0 {
+		drift.NonJSON = true
+		return nil, drift, fmt.Errorf("empty response body")
+	}
+
+	var rawRecords []json.RawMessage
+	if err := json.Unmarshal(trimmedBody, &rawRecords); err != nil {
+		if trimmedBody[0] == '[' || trimmedBody[0] == '{' {
+			drift.TypeMismatches["$root"]++
+			return nil, drift, fmt.Errorf("unexpected response shape: %v", err)
+		}
+
+		drift.NonJSON = true
+		return nil, drift, fmt.Errorf("response is not json, starts with %q", getBodyPrefix(trimmedBody, 64))
+	}
+
+	var tiprankDividends []*entities.TipRankDividend
+	for _, rawRecord := range rawRecords {
+		var record map[string]json.RawMessage
+		if err := json.Unmarshal(rawRecord, &record); err != nil {
+			drift.TypeMismatches["$record"]++
+			continue
+		}
+
+		if !checkTipRankDividendFields(record, fields, drift) {
+			continue
+		}
+
+		var tiprankDividend entities.TipRankDividend
+		if err := json.Unmarshal(rawRecord, &tiprankDividend); err != nil {
+			drift.TypeMismatches["$record"]++
+			continue
+		}
+
+		tiprankDividends = append(tiprankDividends, &tiprankDividend)
+	}
+
+	return tiprankDividends, drift, nil
+}
+
+// checkTipRankDividendFields adds drift of a record, it returns false when a field has an unexpected type.
+// Missing required fields are only reported, such records are left to the validation of the service
+func checkTipRankDividendFields(record map[string]json.RawMessage, fields map[string]expectedField, drift *entities.ResponseDrift) bool {
+	valid := true
+
+	for name, value := range record {
+		field, known := fields[name]
+		if !known {
+			drift.UnknownFields[name]++
+			continue
+		}
+
+		if kind := getJSONKind(value); kind != field.kind && kind != jsonKindNull {
+			drift.TypeMismatches[name]++
+			valid = false
+		}
+	}
+
+	for name, field := range fields {
+		if value, found := record[name]; field.required && (!found || getJSONKind(value) == jsonKindNull) {
+			drift.MissingFields[name]++
+		}
+	}
+
+	return valid
+}
+
+// getJSONKind gets kind of a raw json value
+func getJSONKind(value json.RawMessage) jsonKind {
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 {
+		return jsonKindNull
+	}
+
+	switch value[0] {
+	case '"':
+		return jsonKindString
+	case '{':
+		return jsonKindObject
+	case '[':
+		return jsonKindArray
+	case 't', 'f':
+		return jsonKindBool
+	case 'n':
+		return jsonKindNull
+	default:
+		return jsonKindNumber
+	}
+}
+
+// getBodyPrefix gets the first bytes of a body for logging
+func getBodyPrefix(body []byte, size int) string {
+	if len(body) > size {
+		return string(body[:size])
+	}
+
+	return string(body)
+}
@@ -0,0 +1,146 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeTipRankDividends(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		fields         map[string]expectedField
+		wantTickers    []string
+		wantErr        bool
+		nonJSON        bool
+		unknownFields  map[string]int
+		missingFields  map[string]int
+		typeMismatches map[string]int
+		breaking       bool
+	}{
+		{
+			name:        "expected shape",
+			body:        `[{"ticker":"TSE:RY","company":"Royal Bank","yield":3.9,"amount":1.08,"exDate":"2021-03-01T00:00:00","recDate":null,"payDate":"2021-03-24T00:00:00"}]`,
+			fields:      tiprankDividendFields,
+			wantTickers: []string{"TSE:RY"},
+		},
+		{
+			name:          "unknown field is not breaking",
+			body:          `[{"ticker":"RY","amount":1.08,"exDate":"2021-03-01T00:00:00","sector":"Financial"}]`,
+			fields:        tiprankDividendFields,
+			wantTickers:   []string{"RY"},
+			unknownFields: map[string]int{"sector": 1},
+		},
+		{
+			name:          "missing required field keeps the record",
+			body:          `[{"ticker":"RY","exDate":"2021-03-01T00:00:00"},{"ticker":"TD","amount":null,"exDate":"2021-03-01T00:00:00"}]`,
+			fields:        tiprankDividendFields,
+			wantTickers:   []string{"RY", "TD"},
+			missingFields: map[string]int{"amount": 2},
+			breaking:      true,
+		},
+		{
+			name:           "type mismatch drops the record",
+			body:           `[{"ticker":"RY","amount":"1.08","exDate":"2021-03-01T00:00:00"},{"ticker":"TD","amount":0.79,"exDate":"2021-03-01T00:00:00"}]`,
+			fields:         tiprankDividendFields,
+			wantTickers:    []string{"TD"},
+			typeMismatches: map[string]int{"amount": 1},
+			breaking:       true,
+		},
+		{
+			name:           "record which is not an object",
+			body:           `[1,{"ticker":"RY","amount":1.08,"exDate":"2021-03-01T00:00:00"}]`,
+			fields:         tiprankDividendFields,
+			wantTickers:    []string{"RY"},
+			typeMismatches: map[string]int{"$record": 1},
+			breaking:       true,
+		},
+		{
+			name:        "history record without ticker",
+			body:        `[{"amount":1.08,"exDate":"2021-03-01T00:00:00"}]`,
+			fields:      tiprankDividendHistoryFields,
+			wantTickers: []string{""},
+		},
+		{
+			name:   "empty array",
+			body:   ` [] `,
+			fields: tiprankDividendFields,
+		},
+		{
+			name:           "object instead of array",
+			body:           `{"error":"not found"}`,
+			fields:         tiprankDividendFields,
+			wantErr:        true,
+			typeMismatches: map[string]int{"$root": 1},
+			breaking:       true,
+		},
+		{
+			name:     "html anti-bot page",
+			body:     `<html><body>Please verify you are a human</body></html>`,
+			fields:   tiprankDividendFields,
+			wantErr:  true,
+			nonJSON:  true,
+			breaking: true,
+		},
+		{
+			name:     "empty body",
+			body:     "  ",
+			fields:   tiprankDividendFields,
+			wantErr:  true,
+			nonJSON:  true,
+			breaking: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiprankDividends, drift, err := decodeTipRankDividends([]byte(tt.body), tt.fields)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeTipRankDividends() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var tickers []string
+			for _, tiprankDividend := range tiprankDividends {
+				tickers = append(tickers, tiprankDividend.Ticker)
+			}
+
+			if !reflect.DeepEqual(tickers, tt.wantTickers) {
+				t.Errorf("decoded tickers = %v, want %v", tickers, tt.wantTickers)
+			}
+
+			if drift.NonJSON != tt.nonJSON {
+				t.Errorf("NonJSON = %v, want %v", drift.NonJSON, tt.nonJSON)
+			}
+
+			if !equalCounts(drift.UnknownFields, tt.unknownFields) {
+				t.Errorf("UnknownFields = %v, want %v", drift.UnknownFields, tt.unknownFields)
+			}
+
+			if !equalCounts(drift.MissingFields, tt.missingFields) {
+				t.Errorf("MissingFields = %v, want %v", drift.MissingFields, tt.missingFields)
+			}
+
+			if !equalCounts(drift.TypeMismatches, tt.typeMismatches) {
+				t.Errorf("TypeMismatches = %v, want %v", drift.TypeMismatches, tt.typeMismatches)
+			}
+
+			hasDrift := tt.nonJSON || len(tt.unknownFields) > 0 || len(tt.missingFields) > 0 || len(tt.typeMismatches) > 0
+			if drift.HasDrift() != hasDrift {
+				t.Errorf("HasDrift() = %v, want %v", drift.HasDrift(), hasDrift)
+			}
+
+			if drift.IsBreaking() != tt.breaking {
+				t.Errorf("IsBreaking() = %v, want %v", drift.IsBreaking(), tt.breaking)
+			}
+		})
+	}
+}
+
+// equalCounts compares drift counts, no count is the same as an empty map
+func equalCounts(got map[string]int, want map[string]int) bool {
+	if len(got) == 0 && len(want) == 0 {
+		return true
+	}
+
+	return reflect.DeepEqual(got, want)
+}
@@ -0,0 +1,218 @@
+package sources
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/usecase/source"
+)
+
+// CSVSourceName is the default registry name of CSV source
+const CSVSourceName = "csv"
+
+// csvColumns lists columns of a dividend CSV file, dates are formatted as 2006-01-02
+var csvColumns = []string{"country", "ticker", "name", "yield", "amount", "exDividendDate", "recordDate", "payoutDate"}
+
+// CSVSource gives dividend events of CSV files with a header row, e.g. files exported by another provider
+type CSVSource struct {
+	name string
+	path string // a CSV file, or a directory whose .csv files are all read
+}
+
+// NewCSVSource creates new CSV source of a file or a directory
+func NewCSVSource(name string, path string) *CSVSource {
+	if name == "" {
+		name = CSVSourceName
+	}
+
+	return &CSVSource{
+		name: name,
+		path: path,
+	}
+}
+
+// GetName gets name of the source
+func (s *CSVSource) GetName() string {
+	return s.name
+}
+
+// FetchDividendEvents reads dividend events of a country whose ex-dividend date is between from and to dates
+func (s *CSVSource) FetchDividendEvents(ctx context.Context, country string, from time.Time, to time.Time, handler source.EventHandler) error {
+	files, err := s.getFiles()
+	if err != nil {
+		return err
+	}
+
+	fromDay := from.Format("2006-01-02")
+	toDay := to.Format("2006-01-02")
+
+	var failedFiles []string
+	for _, file := range files {
+		events, err := readCSVFile(file)
+		if err != nil {
+			return err
+		}
+
+		// events of a file are handled as one batch
+		var batch []*entities.DividendEvent
+		for _, event := range events {
+			if event.Country != country || event.ExDividendDate == nil {
+				continue
+			}
+
+			if exDay := event.ExDividendDate.Format("2006-01-02"); exDay < fromDay || exDay > toDay {
+				continue
+			}
+
+			event.Source = s.name
+			batch = append(batch, event)
+		}
+
+		if len(batch) == 0 {
+			continue
+		}
+
+		if _, err := handler(ctx, batch); err != nil {
+			failedFiles = append(failedFiles, filepath.Base(file))
+		}
+	}
+
+	if len(failedFiles) > 0 {
+		return fmt.Errorf("dividend events of %s failed in files %v", country, failedFiles)
+	}
+
+	return nil
+}
+
+// getFiles gets CSV files of the source path
+func (s *CSVSource) getFiles() ([]string, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return []string{s.path}, nil
+	}
+
+	return filepath.Glob(filepath.Join(s.path, "*.csv"))
+}
+
+// readCSVFile reads dividend events of a CSV file, columns are matched by header name
+func readCSVFile(file string) ([]*entities.DividendEvent, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header of %s failed: %v", file, err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	for _, name := range []string{"country", "ticker", "amount", "exDividendDate"} {
+		if _, found := columns[name]; !found {
+			return nil, fmt.Errorf("%s misses column %s, expected columns %v", file, name, csvColumns)
+		}
+	}
+
+	var events []*entities.DividendEvent
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s failed: %v", file, err)
+		}
+
+		event, err := parseCSVRecord(record, columns)
+		if err != nil {
+			return nil, fmt.Errorf("invalid line %d of %s: %v", line, file, err)
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// parseCSVRecord parses a CSV record into dividend event
+func parseCSVRecord(record []string, columns map[string]int) (*entities.DividendEvent, error) {
+	get := func(name string) string {
+		i, found := columns[name]
+		if !found || i >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[i])
+	}
+
+	event := &entities.DividendEvent{
+		Country: get("country"),
+		Ticker:  strings.ToUpper(get("ticker")),
+		Name:    get("name"),
+	}
+
+	var err error
+	if event.Yield, err = parseCSVFloat(get("yield")); err != nil {
+		return nil, fmt.Errorf("invalid yield: %v", err)
+	}
+
+	if event.Amount, err = parseCSVFloat(get("amount")); err != nil {
+		return nil, fmt.Errorf("invalid amount: %v", err)
+	}
+
+	if event.ExDividendDate, err = parseCSVDate(get("exDividendDate")); err != nil {
+		return nil, fmt.Errorf("invalid exDividendDate: %v", err)
+	}
+
+	if event.RecordDate, err = parseCSVDate(get("recordDate")); err != nil {
+		return nil, fmt.Errorf("invalid recordDate: %v", err)
+	}
+
+	if event.DividendDate, err = parseCSVDate(get("payoutDate")); err != nil {
+		return nil, fmt.Errorf("invalid payoutDate: %v", err)
+	}
+
+	return event, nil
+}
+
+// parseCSVFloat parses an optional number
+func parseCSVFloat(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
+
+// parseCSVDate parses an optional date formatted as 2006-01-02
+func parseCSVDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &date, nil
+}
@@ -0,0 +1,71 @@
+package sources
+
+import (
+	"context"
+	"time"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/infrastructure/scraper"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/usecase/source"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/datetime"
+)
+
+// TipRankSourceName is the registry name of TipRank source
+const TipRankSourceName = "tiprank"
+
+// TipRankSource gives dividend events scraped from TipRank
+type TipRankSource struct {
+	jobs *scraper.TipRankDividendScraper
+}
+
+// NewTipRankSource creates new TipRank source of a given scraper, jobs of the source hand their dividends
+// to the source instead of adding them through the dividend service
+func NewTipRankSource(jobs *scraper.TipRankDividendScraper) *TipRankSource {
+	return &TipRankSource{
+		jobs: jobs,
+	}
+}
+
+// GetName gets name of the source
+func (s *TipRankSource) GetName() string {
+	return TipRankSourceName
+}
+
+// FetchDividendEvents scrapes TipRank dividends of a country between from and to dates
+func (s *TipRankSource) FetchDividendEvents(ctx context.Context, country string, from time.Time, to time.Time, handler source.EventHandler) error {
+	sourceHandler := func(ctx context.Context, tiprankDividends []*entities.TipRankDividend, country string) ([]entities.WriteResult, error) {
+		events := make([]*entities.DividendEvent, len(tiprankDividends))
+		for i, tiprankDividend := range tiprankDividends {
+			events[i] = toDividendEvent(tiprankDividend, country)
+		}
+
+		// events follow the order of the dividends, so do their results
+		return handler(ctx, events)
+	}
+
+	spec := scraper.JobSpec{
+		From:      scraper.AbsoluteDate(from),
+		To:        scraper.AbsoluteDate(to),
+		Countries: []string{country},
+	}
+
+	return s.jobs.StartSourceJob(ctx, spec, sourceHandler)
+}
+
+// toDividendEvent converts TipRank dividend to dividend event, dates TipRank sends in another layout are dropped
+func toDividendEvent(tiprankDividend *entities.TipRankDividend, country string) *entities.DividendEvent {
+	event := &entities.DividendEvent{
+		Source:  TipRankSourceName,
+		Country: country,
+		Ticker:  tiprankDividend.Ticker,
+		Name:    tiprankDividend.Name,
+		Yield:   tiprankDividend.Yield,
+		Amount:  tiprankDividend.Amount,
+	}
+
+	event.ExDividendDate, _ = datetime.GetStarDateFromString(tiprankDividend.ExDividendDate)
+	event.RecordDate, _ = datetime.GetStarDateFromString(tiprankDividend.RecordDate)
+	event.DividendDate, _ = datetime.GetStarDateFromString(tiprankDividend.DividendDate)
+
+	return event
+}
@@ -0,0 +1,30 @@
+package backfill
+
+import (
+	"context"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+)
+
+///////////////////////////////////////////////////////////
+// Backfill Plan Repository Interface
+///////////////////////////////////////////////////////////
+
+// Reader interface
+type Reader interface {
+	FindBackfillPlan(ctx context.Context, id string) (*entities.BackfillPlan, error)
+	FindBackfillPlans(ctx context.Context) ([]*entities.BackfillPlan, error)
+}
+
+// Writer interface
+type Writer interface {
+	InsertBackfillPlan(ctx context.Context, plan *entities.BackfillPlan) (string, error)
+	UpdateBackfillPlanStatus(ctx context.Context, id string, status entities.BackfillStatus) error
+	UpdateBackfillTask(ctx context.Context, id string, task *entities.BackfillTask) error
+}
+
+// Repo interface
+type Repo interface {
+	Reader
+	Writer
+}
@@ -0,0 +1,158 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+
+	logger "github.com/lenoobz/aws-lambda-logger"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+)
+
+// Service sector
+type Service struct {
+	backfillRepo Repo
+	log          logger.ContextLog
+}
+
+// NewService create new service
+func NewService(backfillRepo Repo, log logger.ContextLog) *Service {
+	return &Service{
+		backfillRepo: backfillRepo,
+		log:          log,
+	}
+}
+
+// CreatePlan creates new backfill plan where all given tasks are pending
+func (s *Service) CreatePlan(ctx context.Context, plan *entities.BackfillPlan, tasks []*entities.ScrapeTask) (*entities.BackfillPlan, error) {
+	s.log.Info(ctx, "creating backfill plan", "from", plan.From, "to", plan.To, "countries", plan.Countries, "tasks", len(tasks))
+
+	if len(tasks) == 0 {
+		s.log.Error(ctx, "backfill plan has no task")
+		return nil, fmt.Errorf("backfill plan has no task")
+	}
+
+	plan.Status = entities.BackfillStatusPending
+	plan.Tasks = nil
+	for _, task := range tasks {
+		plan.Tasks = append(plan.Tasks, &entities.BackfillTask{
+			Country: task.Country,
+			Date:    task.Date,
+			Status:  entities.BackfillStatusPending,
+		})
+	}
+
+	id, err := s.backfillRepo.InsertBackfillPlan(ctx, plan)
+	if err != nil {
+		s.log.Error(ctx, "insert backfill plan failed", "error", err)
+		return nil, err
+	}
+
+	plan.ID = id
+	return plan, nil
+}
+
+// GetPlan gets backfill plan of a given id
+func (s *Service) GetPlan(ctx context.Context, id string) (*entities.BackfillPlan, error) {
+	s.log.Info(ctx, "getting backfill plan", "id", id)
+
+	plan, err := s.backfillRepo.FindBackfillPlan(ctx, id)
+	if err != nil {
+		s.log.Error(ctx, "find backfill plan failed", "error", err, "id", id)
+		return nil, err
+	}
+
+	if plan == nil {
+		s.log.Error(ctx, "backfill plan not found", "id", id)
+		return nil, fmt.Errorf("backfill plan %s not found", id)
+	}
+
+	return plan, nil
+}
+
+// ListPlans gets all backfill plans
+func (s *Service) ListPlans(ctx context.Context) ([]*entities.BackfillPlan, error) {
+	s.log.Info(ctx, "listing backfill plans")
+	return s.backfillRepo.FindBackfillPlans(ctx)
+}
+
+// StartPlan marks a backfill plan as running and gets its remaining tasks
+func (s *Service) StartPlan(ctx context.Context, id string) ([]*entities.ScrapeTask, error) {
+	plan, err := s.GetPlan(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if plan.Status == entities.BackfillStatusCancelled || plan.Status == entities.BackfillStatusDone {
+		s.log.Error(ctx, "backfill plan cannot be resumed", "id", id, "status", plan.Status)
+		return nil, fmt.Errorf("backfill plan %s is %s", id, plan.Status)
+	}
+
+	if err := s.backfillRepo.UpdateBackfillPlanStatus(ctx, id, entities.BackfillStatusRunning); err != nil {
+		s.log.Error(ctx, "update backfill plan status failed", "error", err, "id", id)
+		return nil, err
+	}
+
+	return plan.GetRemainingTasks(), nil
+}
+
+// CompleteTask checkpoints a task of a backfill plan, the task failed if err is not nil
+func (s *Service) CompleteTask(ctx context.Context, id string, task *entities.ScrapeTask, err error) error {
+	backfillTask := &entities.BackfillTask{
+		Country: task.Country,
+		Date:    task.Date,
+		Status:  entities.BackfillStatusDone,
+	}
+
+	if err != nil {
+		backfillTask.Status = entities.BackfillStatusFailed
+		backfillTask.Error = err.Error()
+	}
+
+	if err := s.backfillRepo.UpdateBackfillTask(ctx, id, backfillTask); err != nil {
+		s.log.Error(ctx, "update backfill task failed", "error", err, "id", id, "country", task.Country, "date", task.Date)
+		return err
+	}
+
+	return nil
+}
+
+// FinishPlan marks a backfill plan as done when all of its tasks are done, as failed when the tasks left
+// all failed, otherwise it stays pending so that it can be resumed
+func (s *Service) FinishPlan(ctx context.Context, id string) (*entities.BackfillPlan, error) {
+	plan, err := s.GetPlan(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if plan.Status == entities.BackfillStatusCancelled {
+		return plan, nil
+	}
+
+	counts := plan.CountTasks()
+	switch {
+	case counts[entities.BackfillStatusPending] > 0:
+		plan.Status = entities.BackfillStatusPending
+	case counts[entities.BackfillStatusFailed] > 0:
+		plan.Status = entities.BackfillStatusFailed
+	default:
+		plan.Status = entities.BackfillStatusDone
+	}
+
+	if err := s.backfillRepo.UpdateBackfillPlanStatus(ctx, id, plan.Status); err != nil {
+		s.log.Error(ctx, "update backfill plan status failed", "error", err, "id", id)
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// CancelPlan marks a backfill plan as cancelled so that it cannot be resumed anymore
+func (s *Service) CancelPlan(ctx context.Context, id string) error {
+	s.log.Info(ctx, "cancelling backfill plan", "id", id)
+
+	if _, err := s.GetPlan(ctx, id); err != nil {
+		return err
+	}
+
+	return s.backfillRepo.UpdateBackfillPlanStatus(ctx, id, entities.BackfillStatusCancelled)
+}
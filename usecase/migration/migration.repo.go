@@ -0,0 +1,31 @@
+package migration
+
+import (
+	"context"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+)
+
+///////////////////////////////////////////////////////////
+// Migration Repository Interface
+///////////////////////////////////////////////////////////
+
+// Reader interface
+type Reader interface {
+	GetSchemaVersion() (int, error)
+	GetMigrations() ([]*entities.Migration, error)
+	FindAppliedMigrations(ctx context.Context) (map[int]*entities.Migration, error)
+}
+
+// Writer interface
+type Writer interface {
+	RunMigration(ctx context.Context, version int, dryRun bool) (int64, error)
+	InsertMigration(ctx context.Context, migration *entities.Migration) error
+	ReconcileIndexes(ctx context.Context, dryRun bool) ([]*entities.IndexChange, error)
+}
+
+// Repo interface
+type Repo interface {
+	Reader
+	Writer
+}
@@ -0,0 +1,110 @@
+package migration
+
+import (
+	"context"
+
+	logger "github.com/lenoobz/aws-lambda-logger"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+)
+
+// Service sector
+type Service struct {
+	migrationRepo Repo
+	log           logger.ContextLog
+}
+
+// NewService create new service
+func NewService(migrationRepo Repo, log logger.ContextLog) *Service {
+	return &Service{
+		migrationRepo: migrationRepo,
+		log:           log,
+	}
+}
+
+// Status gets migrations up to the configured schema version and whether they have been applied
+func (s *Service) Status(ctx context.Context) (*entities.MigrationReport, error) {
+	s.log.Info(ctx, "getting migration status")
+
+	schemaVersion, err := s.migrationRepo.GetSchemaVersion()
+	if err != nil {
+		s.log.Error(ctx, "get schema version failed", "error", err)
+		return nil, err
+	}
+
+	migrations, err := s.migrationRepo.GetMigrations()
+	if err != nil {
+		s.log.Error(ctx, "get migrations failed", "error", err)
+		return nil, err
+	}
+
+	applied, err := s.migrationRepo.FindAppliedMigrations(ctx)
+	if err != nil {
+		s.log.Error(ctx, "find applied migrations failed", "error", err)
+		return nil, err
+	}
+
+	for i, migration := range migrations {
+		if appliedMigration, ok := applied[migration.Version]; ok {
+			appliedMigration.Name = migration.Name
+			migrations[i] = appliedMigration
+		}
+	}
+
+	return &entities.MigrationReport{
+		SchemaVersion: schemaVersion,
+		Migrations:    migrations,
+	}, nil
+}
+
+// Up reconciles indexes then runs pending migrations in order, recording each of them. Indexes come first
+// so that migrations upserting documents find them by index. It stops at the first failed migration so later
+// ones never run on documents of an older schema. A dry run gets the index changes and the number of documents
+// each pending migration would change without making them
+func (s *Service) Up(ctx context.Context, dryRun bool) (*entities.MigrationReport, error) {
+	report, err := s.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.DryRun = dryRun
+
+	report.Indexes, err = s.ReconcileIndexes(ctx, dryRun)
+	if err != nil {
+		return report, err
+	}
+
+	for _, migration := range report.GetPendingMigrations() {
+		affected, err := s.migrationRepo.RunMigration(ctx, migration.Version, dryRun)
+		if err != nil {
+			s.log.Error(ctx, "run migration failed", "error", err, "version", migration.Version)
+			return report, err
+		}
+		migration.Affected = affected
+
+		if dryRun {
+			continue
+		}
+
+		if err := s.migrationRepo.InsertMigration(ctx, migration); err != nil {
+			s.log.Error(ctx, "insert migration failed", "error", err, "version", migration.Version)
+			return report, err
+		}
+		migration.Applied = true
+
+		s.log.Info(ctx, "migration applied", "version", migration.Version, "name", migration.Name, "affected", affected)
+	}
+
+	return report, nil
+}
+
+// ReconcileIndexes creates missing and changed indexes declared in code, a dry run only reports them
+func (s *Service) ReconcileIndexes(ctx context.Context, dryRun bool) ([]*entities.IndexChange, error) {
+	s.log.Info(ctx, "reconciling indexes", "dryRun", dryRun)
+
+	changes, err := s.migrationRepo.ReconcileIndexes(ctx, dryRun)
+	if err != nil {
+		s.log.Error(ctx, "reconcile indexes failed", "error", err)
+		return changes, err
+	}
+
+	return changes, nil
+}
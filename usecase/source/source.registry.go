@@ -0,0 +1,61 @@
+package source
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry holds dividend sources by name, it is safe for concurrent use
+type Registry struct {
+	sources map[string]DividendSource
+	mu      sync.RWMutex
+}
+
+// NewRegistry create new registry
+func NewRegistry() *Registry {
+	return &Registry{
+		sources: map[string]DividendSource{},
+	}
+}
+
+// Register adds a dividend source, names are unique
+func (r *Registry) Register(dividendSource DividendSource) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := dividendSource.GetName()
+	if _, found := r.sources[name]; found {
+		return fmt.Errorf("dividend source %s is already registered", name)
+	}
+
+	r.sources[name] = dividendSource
+	return nil
+}
+
+// Get gets dividend source of a given name
+func (r *Registry) Get(name string) (DividendSource, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	dividendSource, found := r.sources[name]
+	if !found {
+		return nil, fmt.Errorf("unknown dividend source %s", name)
+	}
+
+	return dividendSource, nil
+}
+
+// GetNames gets sorted names of all registered sources
+func (r *Registry) GetNames() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var names []string
+	for name := range r.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
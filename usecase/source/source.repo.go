@@ -0,0 +1,24 @@
+package source
+
+import (
+	"context"
+	"time"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+)
+
+///////////////////////////////////////////////////////////
+// Dividend Source Interface
+///////////////////////////////////////////////////////////
+
+// EventHandler is called with batches of dividend events of a source, e.g. the events of a response or a file,
+// it may be called concurrently. Results follow the order of the events, when err is set the events without result
+// failed. An error of the handler only fails the batch, sources go on with the next batches
+type EventHandler func(ctx context.Context, events []*entities.DividendEvent) ([]entities.WriteResult, error)
+
+// DividendSource gives normalized dividend events of a market
+type DividendSource interface {
+	GetName() string
+	// FetchDividendEvents calls handler with dividend events of a TipRank country code between from and to dates, both included
+	FetchDividendEvents(ctx context.Context, country string, from time.Time, to time.Time, handler EventHandler) error
+}
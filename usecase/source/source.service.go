@@ -0,0 +1,123 @@
+package source
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	logger "github.com/lenoobz/aws-lambda-logger"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/usecase/tiprank"
+)
+
+// Service runs dividend sources into the dividend service
+type Service struct {
+	registry               *Registry
+	tiprankDividendService *tiprank.Service
+	log                    logger.ContextLog
+}
+
+// NewService create new service
+func NewService(registry *Registry, tiprankDividendService *tiprank.Service, log logger.ContextLog) *Service {
+	return &Service{
+		registry:               registry,
+		tiprankDividendService: tiprankDividendService,
+		log:                    log,
+	}
+}
+
+// Run pulls dividend events of given countries and dates from given sources, all registered sources by default,
+// and adds them through the dividend service. A failing source does not stop the other sources
+func (s *Service) Run(ctx context.Context, sourceNames []string, countries []string, from time.Time, to time.Time) ([]*entities.SourceRunResult, error) {
+	if len(sourceNames) == 0 {
+		sourceNames = s.registry.GetNames()
+	}
+
+	var dividendSources []DividendSource
+	for _, name := range sourceNames {
+		dividendSource, err := s.registry.Get(name)
+		if err != nil {
+			s.log.Error(ctx, "get dividend source failed", "error", err, "source", name)
+			return nil, err
+		}
+
+		dividendSources = append(dividendSources, dividendSource)
+	}
+
+	var results []*entities.SourceRunResult
+	for _, dividendSource := range dividendSources {
+		result := s.runSource(ctx, dividendSource, countries, from, to)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// runSource pulls dividend events of a source
+func (s *Service) runSource(ctx context.Context, dividendSource DividendSource, countries []string, from time.Time, to time.Time) *entities.SourceRunResult {
+	result := &entities.SourceRunResult{
+		Source: dividendSource.GetName(),
+	}
+
+	// handlers may be called concurrently
+	var mu sync.Mutex
+
+	handler := func(ctx context.Context, events []*entities.DividendEvent) ([]entities.WriteResult, error) {
+		// events of a batch may be of several countries, indexes map results back to the events
+		tiprankDividendsByCountry := map[string][]*entities.TipRankDividend{}
+		indexesByCountry := map[string][]int{}
+		for i, event := range events {
+			event.Source = result.Source
+			tiprankDividendsByCountry[event.Country] = append(tiprankDividendsByCountry[event.Country], event.ToTipRankDividend())
+			indexesByCountry[event.Country] = append(indexesByCountry[event.Country], i)
+		}
+
+		eventResults := make([]entities.WriteResult, len(events))
+
+		var handlerErr error
+		for country, tiprankDividends := range tiprankDividendsByCountry {
+			writeResults, err := s.tiprankDividendService.AddTipRankDividends(ctx, tiprankDividends, country)
+			if err != nil {
+				s.log.Error(ctx, "add dividend events failed", "error", err, "source", result.Source, "country", country)
+				handlerErr = err
+			}
+
+			mu.Lock()
+			result.Events += len(tiprankDividends)
+			for i, index := range indexesByCountry[country] {
+				var writeResult entities.WriteResult
+				if i < len(writeResults) {
+					writeResult = writeResults[i]
+				}
+				eventResults[index] = writeResult
+
+				switch writeResult {
+				case entities.WriteResultInserted:
+					result.Inserted++
+				case entities.WriteResultUpdated:
+					result.Updated++
+				case entities.WriteResultUnchanged:
+					result.Unchanged++
+				case entities.WriteResultRejected:
+					result.Rejected++
+				default:
+					result.Failed++
+				}
+			}
+			mu.Unlock()
+		}
+
+		return eventResults, handlerErr
+	}
+
+	for _, country := range countries {
+		s.log.Info(ctx, "fetching dividend events", "source", result.Source, "country", country, "from", from.Format("2006-01-02"), "to", to.Format("2006-01-02"))
+
+		if err := dividendSource.FetchDividendEvents(ctx, country, from, to, handler); err != nil {
+			s.log.Error(ctx, "fetch dividend events failed", "error", err, "source", result.Source, "country", country)
+			result.Errors = append(result.Errors, err.Error())
+		}
+	}
+
+	return result
+}
@@ -2,6 +2,7 @@ package tiprank
 
 import (
 	"context"
+	"time"
 
 	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
 )
@@ -12,11 +13,24 @@ import (
 
 // Reader interface
 type Reader interface {
+	GetByTicker(ctx context.Context, ticker string) (*entities.DividendStock, error)
+	GetBySymbol(ctx context.Context, symbol *entities.Symbol) (*entities.DividendStock, error)
+	GetByTickers(ctx context.Context, tickers []string) ([]*entities.DividendStock, error)
+	ListByExDateRange(ctx context.Context, from time.Time, to time.Time, exchanges []string) ([]*entities.DividendStock, error)
+	ListByPayDateRange(ctx context.Context, from time.Time, to time.Time, exchanges []string) ([]*entities.DividendStock, error)
+	ListUpcoming(ctx context.Context, today time.Time, days int, exchanges []string) ([]*entities.DividendStock, error)
+	ListAll(ctx context.Context, cursor string, limit int) (*entities.DividendStockPage, error)
+	GetResponseHash(ctx context.Context, country string, date string) (string, error)
 }
 
 // Writer interface
 type Writer interface {
-	InsertTipRankDividend(ctx context.Context, tiprankDividend *entities.TipRankDividend, currency string) error
+	InsertTipRankDividends(ctx context.Context, tiprankDividendWrites []*entities.TipRankDividendWrite) ([]entities.WriteResult, error)
+	InsertTipRankDividendHistory(ctx context.Context, ticker string, symbol *entities.Symbol, tiprankDividends []*entities.TipRankDividend, currency string) (entities.WriteResult, error)
+	InsertQuarantinedResponse(ctx context.Context, quarantinedResponse *entities.QuarantinedResponse) error
+	InsertRejectedDividend(ctx context.Context, rejectedDividend *entities.RejectedDividend) error
+	InsertResponseHash(ctx context.Context, country string, date string, hash string) error
+	GetVersionConflicts() int
 }
 
 // Repo interface
@@ -2,10 +2,18 @@ package tiprank
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	logger "github.com/lenoobz/aws-lambda-logger"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/config"
 	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/calendar"
 	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/currency"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/datetime"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/runid"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/symbology"
 )
 
 // Service sector
@@ -22,14 +30,308 @@ func NewService(tiprankDividendRepo Repo, log logger.ContextLog) *Service {
 	}
 }
 
-// AddTipRankDividend add TipRank dividend
-func (s *Service) AddTipRankDividend(ctx context.Context, tiprankDividend *entities.TipRankDividend, country string) error {
-	s.log.Info(ctx, "adding TipRank dividend", "ticker", tiprankDividend.Ticker)
+// AddTipRankDividend add TipRank dividend, an invalid dividend is kept aside as a reject and gives WriteResultRejected
+func (s *Service) AddTipRankDividend(ctx context.Context, tiprankDividend *entities.TipRankDividend, country string) (entities.WriteResult, error) {
+	results, err := s.AddTipRankDividends(ctx, []*entities.TipRankDividend{tiprankDividend}, country)
+	if err != nil {
+		return "", err
+	}
+
+	return results[0], nil
+}
+
+// AddTipRankDividends adds TipRank dividends of a country with one batched write, e.g. all dividends of a response.
+// Invalid dividends are kept aside as rejects and give WriteResultRejected. Results follow the order of
+// the dividends, a dividend which could not be added has no result and the error tells how many failed
+func (s *Service) AddTipRankDividends(ctx context.Context, tiprankDividends []*entities.TipRankDividend, country string) ([]entities.WriteResult, error) {
+	s.log.Info(ctx, "adding TipRank dividends", "country", country, "count", len(tiprankDividends))
 
 	currency, err := currency.GetCountryCurrency(country)
 	if err != nil {
 		s.log.Error(ctx, "get country currency failed", "country", country)
 	}
 
-	return s.tiprankDividendRepo.InsertTipRankDividend(ctx, tiprankDividend, currency)
+	// ticker format and dividend dates are only checked for known markets
+	market, _ := config.GetMarket(country)
+
+	results := make([]entities.WriteResult, len(tiprankDividends))
+
+	var tiprankDividendWrites []*entities.TipRankDividendWrite
+	var writeIndexes []int
+	var failed int
+	for i, tiprankDividend := range tiprankDividends {
+		if reasons := validateTipRankDividend(tiprankDividend); len(reasons) > 0 {
+			if err := s.rejectTipRankDividend(ctx, tiprankDividend, country, reasons); err != nil {
+				failed++
+				continue
+			}
+
+			results[i] = entities.WriteResultRejected
+			continue
+		}
+
+		if market != nil {
+			if valid, _ := market.IsValidTicker(tiprankDividend.Ticker); !valid {
+				s.log.Warn(ctx, "ticker does not match market ticker format", "ticker", tiprankDividend.Ticker, "country", country)
+			}
+
+			s.checkDividendDates(ctx, tiprankDividend, market)
+		}
+
+		symbol, err := symbology.Normalize(tiprankDividend.Ticker, country)
+		if err != nil {
+			s.log.Error(ctx, "normalize ticker failed", "error", err, "ticker", tiprankDividend.Ticker, "country", country)
+			failed++
+			continue
+		}
+
+		tiprankDividendWrites = append(tiprankDividendWrites, &entities.TipRankDividendWrite{
+			TipRankDividend: tiprankDividend,
+			Symbol:          symbol,
+			Currency:        currency,
+		})
+		writeIndexes = append(writeIndexes, i)
+	}
+
+	writeResults, err := s.tiprankDividendRepo.InsertTipRankDividends(ctx, tiprankDividendWrites)
+	if err != nil {
+		s.log.Error(ctx, "insert TipRank dividends failed", "error", err, "country", country)
+	}
+
+	for i, writeResult := range writeResults {
+		results[writeIndexes[i]] = writeResult
+		if writeResult == "" {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("%d of %d TipRank dividends of %s could not be added", failed, len(tiprankDividends), country)
+	}
+
+	return results, nil
+}
+
+// AddTipRankDividendHistory merges dividend history of a ticker, invalid dividends are kept aside as rejects
+// and the number of rejected dividends is returned with the write result
+func (s *Service) AddTipRankDividendHistory(ctx context.Context, ticker string, tiprankDividends []*entities.TipRankDividend, country string) (entities.WriteResult, int, error) {
+	s.log.Info(ctx, "adding TipRank dividend history", "ticker", ticker, "count", len(tiprankDividends))
+
+	var validDividends []*entities.TipRankDividend
+	for _, tiprankDividend := range tiprankDividends {
+		reasons := validateTipRankDividend(tiprankDividend)
+		if len(reasons) == 0 {
+			validDividends = append(validDividends, tiprankDividend)
+			continue
+		}
+
+		if err := s.rejectTipRankDividend(ctx, tiprankDividend, country, reasons); err != nil {
+			return "", 0, err
+		}
+	}
+
+	rejected := len(tiprankDividends) - len(validDividends)
+	if len(validDividends) == 0 && rejected > 0 {
+		return entities.WriteResultRejected, rejected, nil
+	}
+
+	currency, err := currency.GetCountryCurrency(country)
+	if err != nil {
+		s.log.Error(ctx, "get country currency failed", "country", country)
+	}
+
+	symbol, err := symbology.Normalize(ticker, country)
+	if err != nil {
+		s.log.Error(ctx, "normalize ticker failed", "error", err, "ticker", ticker, "country", country)
+		return "", rejected, err
+	}
+
+	if market, err := config.GetMarket(country); err == nil {
+		for _, tiprankDividend := range validDividends {
+			s.checkDividendDates(ctx, tiprankDividend, market)
+		}
+	}
+
+	result, err := s.tiprankDividendRepo.InsertTipRankDividendHistory(ctx, strings.ToUpper(ticker), symbol, validDividends, currency)
+	return result, rejected, err
+}
+
+// rejectTipRankDividend keeps aside an invalid TipRank dividend with the reasons it failed validation
+func (s *Service) rejectTipRankDividend(ctx context.Context, tiprankDividend *entities.TipRankDividend, country string, reasons []string) error {
+	s.log.Warn(ctx, "rejecting TipRank dividend", "ticker", tiprankDividend.Ticker, "country", country, "reasons", reasons)
+
+	rejectedDividend := &entities.RejectedDividend{
+		Country: country,
+		RunID:   runid.FromContext(ctx),
+		Reasons: reasons,
+		Record:  tiprankDividend,
+	}
+
+	if err := s.tiprankDividendRepo.InsertRejectedDividend(ctx, rejectedDividend); err != nil {
+		s.log.Error(ctx, "insert rejected dividend failed", "error", err, "ticker", tiprankDividend.Ticker, "country", country)
+		return err
+	}
+
+	return nil
+}
+
+// checkDividendDates warns about ex-dividend, record and payout dates which are not business days of the market
+func (s *Service) checkDividendDates(ctx context.Context, tiprankDividend *entities.TipRankDividend, market *config.MarketConfig) {
+	marketCalendar, err := calendar.GetCalendar(market.Calendar)
+	if err != nil {
+		s.log.Warn(ctx, "get market calendar failed", "error", err, "country", market.Code)
+		return
+	}
+
+	dates := map[string]string{
+		"exDividendDate": tiprankDividend.ExDividendDate,
+		"recordDate":     tiprankDividend.RecordDate,
+		"dividendDate":   tiprankDividend.DividendDate,
+	}
+
+	for field, value := range dates {
+		date, err := datetime.GetStarDateFromString(value)
+		if err != nil || date == nil || !marketCalendar.Covers(*date) {
+			continue
+		}
+
+		if err := marketCalendar.ValidateBusinessDay(*date); err != nil {
+			s.log.Warn(ctx, "dividend date is not a business day", "ticker", tiprankDividend.Ticker, "field", field, "reason", err.Error())
+		}
+	}
+}
+
+// QuarantineResponse keeps aside a raw TipRank response whose shape drifted
+func (s *Service) QuarantineResponse(ctx context.Context, quarantinedResponse *entities.QuarantinedResponse) error {
+	s.log.Warn(ctx, "quarantining TipRank response", "country", quarantinedResponse.Country, "date", quarantinedResponse.Date, "ticker", quarantinedResponse.Ticker, "reason", quarantinedResponse.Reason)
+	return s.tiprankDividendRepo.InsertQuarantinedResponse(ctx, quarantinedResponse)
+}
+
+// IsResponseUnchanged checks whether a response of a given country and date has the same content hash as the last processed one
+func (s *Service) IsResponseUnchanged(ctx context.Context, country string, date string, hash string) (bool, error) {
+	savedHash, err := s.tiprankDividendRepo.GetResponseHash(ctx, country, date)
+	if err != nil {
+		s.log.Error(ctx, "get response hash failed", "error", err, "country", country, "date", date)
+		return false, err
+	}
+
+	return savedHash != "" && savedHash == hash, nil
+}
+
+// SaveResponseHash saves content hash of a processed response of a given country and date
+func (s *Service) SaveResponseHash(ctx context.Context, country string, date string, hash string) error {
+	return s.tiprankDividendRepo.InsertResponseHash(ctx, country, date, hash)
+}
+
+// GetVersionConflicts gets how many dividend writes lost to a concurrent writer, e.g. a backfill, and were tried again
+func (s *Service) GetVersionConflicts() int {
+	return s.tiprankDividendRepo.GetVersionConflicts()
+}
+
+// GetByTicker gets TipRank dividend of a given ticker
+func (s *Service) GetByTicker(ctx context.Context, ticker string) (*entities.DividendStock, error) {
+	s.log.Info(ctx, "getting TipRank dividend", "ticker", ticker)
+	return s.tiprankDividendRepo.GetByTicker(ctx, ticker)
+}
+
+// GetBySymbol gets TipRank dividend of a ticker of a given TipRank country, keyed on its canonical symbol
+func (s *Service) GetBySymbol(ctx context.Context, ticker string, country string) (*entities.DividendStock, error) {
+	s.log.Info(ctx, "getting TipRank dividend", "ticker", ticker, "country", country)
+
+	symbol, err := symbology.Normalize(ticker, country)
+	if err != nil {
+		s.log.Error(ctx, "normalize ticker failed", "error", err, "ticker", ticker, "country", country)
+		return nil, err
+	}
+
+	return s.tiprankDividendRepo.GetBySymbol(ctx, symbol)
+}
+
+// GetByTickers gets TipRank dividends of given tickers
+func (s *Service) GetByTickers(ctx context.Context, tickers []string) ([]*entities.DividendStock, error) {
+	s.log.Info(ctx, "getting TipRank dividends", "tickers", tickers)
+	return s.tiprankDividendRepo.GetByTickers(ctx, tickers)
+}
+
+// ListByExDateRange gets TipRank dividends with an ex-dividend date between from and to dates of a TipRank country,
+// all countries when country is empty
+func (s *Service) ListByExDateRange(ctx context.Context, from time.Time, to time.Time, country string) ([]*entities.DividendStock, error) {
+	s.log.Info(ctx, "listing TipRank dividends by ex-dividend date", "from", from.Format("2006-01-02"), "to", to.Format("2006-01-02"), "country", country)
+
+	exchanges, err := getMarketExchanges(country)
+	if err != nil {
+		s.log.Error(ctx, "get market exchanges failed", "error", err, "country", country)
+		return nil, err
+	}
+
+	return s.tiprankDividendRepo.ListByExDateRange(ctx, from, to, exchanges)
+}
+
+// ListByPayDateRange gets TipRank dividends with a payout date between from and to dates of a TipRank country,
+// all countries when country is empty
+func (s *Service) ListByPayDateRange(ctx context.Context, from time.Time, to time.Time, country string) ([]*entities.DividendStock, error) {
+	s.log.Info(ctx, "listing TipRank dividends by payout date", "from", from.Format("2006-01-02"), "to", to.Format("2006-01-02"), "country", country)
+
+	exchanges, err := getMarketExchanges(country)
+	if err != nil {
+		s.log.Error(ctx, "get market exchanges failed", "error", err, "country", country)
+		return nil, err
+	}
+
+	return s.tiprankDividendRepo.ListByPayDateRange(ctx, from, to, exchanges)
+}
+
+// ListUpcoming gets TipRank dividends going ex-dividend in the next days of a TipRank country, today of the market
+// included. All countries from today in UTC when country is empty
+func (s *Service) ListUpcoming(ctx context.Context, days int, country string) ([]*entities.DividendStock, error) {
+	s.log.Info(ctx, "listing upcoming TipRank dividends", "days", days, "country", country)
+
+	if days < 0 {
+		return nil, fmt.Errorf("invalid number of days %d", days)
+	}
+
+	today := time.Now().UTC()
+	if country != "" {
+		market, err := config.GetMarket(country)
+		if err != nil {
+			s.log.Error(ctx, "get market failed", "error", err, "country", country)
+			return nil, err
+		}
+
+		location, err := market.GetLocation()
+		if err != nil {
+			s.log.Error(ctx, "get market location failed", "error", err, "country", country)
+			return nil, err
+		}
+
+		today = time.Now().In(location)
+	}
+
+	exchanges, err := getMarketExchanges(country)
+	if err != nil {
+		s.log.Error(ctx, "get market exchanges failed", "error", err, "country", country)
+		return nil, err
+	}
+
+	return s.tiprankDividendRepo.ListUpcoming(ctx, today, days, exchanges)
+}
+
+// ListAll gets a page of TipRank dividends, cursor is the next cursor of the previous page, empty for the first page
+func (s *Service) ListAll(ctx context.Context, cursor string, limit int) (*entities.DividendStockPage, error) {
+	s.log.Info(ctx, "listing TipRank dividends", "cursor", cursor, "limit", limit)
+	return s.tiprankDividendRepo.ListAll(ctx, cursor, limit)
+}
+
+// getMarketExchanges gets exchanges of a TipRank country, none when country is empty
+func getMarketExchanges(country string) ([]string, error) {
+	if country == "" {
+		return nil, nil
+	}
+
+	market, err := config.GetMarket(country)
+	if err != nil {
+		return nil, err
+	}
+
+	return market.GetExchanges(), nil
 }
@@ -0,0 +1,55 @@
+package tiprank
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/utils/datetime"
+)
+
+// validateTipRankDividend checks that a TipRank dividend can be stored, it returns why it cannot, nothing if it is valid.
+// A dividend needs a ticker, an ex-dividend date and a positive amount, and its record and payout dates,
+// when known, must not come before its ex-dividend date
+func validateTipRankDividend(tiprankDividend *entities.TipRankDividend) []string {
+	var reasons []string
+
+	if strings.TrimSpace(tiprankDividend.Ticker) == "" {
+		reasons = append(reasons, "missing ticker")
+	}
+
+	if tiprankDividend.Amount <= 0 {
+		reasons = append(reasons, fmt.Sprintf("amount %v is not positive", tiprankDividend.Amount))
+	}
+
+	exDividendDate, err := datetime.GetStarDateFromString(tiprankDividend.ExDividendDate)
+	if err != nil {
+		reasons = append(reasons, fmt.Sprintf("invalid exDividendDate %q", tiprankDividend.ExDividendDate))
+	} else if exDividendDate == nil {
+		reasons = append(reasons, "missing exDividendDate")
+	}
+
+	recordDate, err := datetime.GetStarDateFromString(tiprankDividend.RecordDate)
+	if err != nil {
+		reasons = append(reasons, fmt.Sprintf("invalid recordDate %q", tiprankDividend.RecordDate))
+	}
+
+	dividendDate, err := datetime.GetStarDateFromString(tiprankDividend.DividendDate)
+	if err != nil {
+		reasons = append(reasons, fmt.Sprintf("invalid payoutDate %q", tiprankDividend.DividendDate))
+	}
+
+	if exDividendDate != nil && recordDate != nil && recordDate.Before(*exDividendDate) {
+		reasons = append(reasons, "recordDate is before exDividendDate")
+	}
+
+	if exDividendDate != nil && dividendDate != nil && dividendDate.Before(*exDividendDate) {
+		reasons = append(reasons, "payoutDate is before exDividendDate")
+	}
+
+	if recordDate != nil && dividendDate != nil && dividendDate.Before(*recordDate) {
+		reasons = append(reasons, "payoutDate is before recordDate")
+	}
+
+	return reasons
+}
@@ -0,0 +1,90 @@
+package tiprank
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+)
+
+func TestValidateTipRankDividend(t *testing.T) {
+	tests := []struct {
+		name            string
+		tiprankDividend entities.TipRankDividend
+		want            []string
+	}{
+		{
+			name: "valid",
+			tiprankDividend: entities.TipRankDividend{
+				Ticker:         "TSE:RY",
+				Amount:         1.08,
+				ExDividendDate: "2021-03-01T00:00:00",
+				RecordDate:     "2021-03-02T00:00:00",
+				DividendDate:   "2021-03-24T00:00:00",
+			},
+		},
+		{
+			name: "unknown record and payout dates",
+			tiprankDividend: entities.TipRankDividend{
+				Ticker:         "RY",
+				Amount:         1.08,
+				ExDividendDate: "2021-03-01T00:00:00",
+			},
+		},
+		{
+			name: "missing ticker, amount and ex-dividend date",
+			tiprankDividend: entities.TipRankDividend{
+				Ticker: " ",
+			},
+			want: []string{"missing ticker", "amount 0 is not positive", "missing exDividendDate"},
+		},
+		{
+			name: "negative amount",
+			tiprankDividend: entities.TipRankDividend{
+				Ticker:         "RY",
+				Amount:         -1.08,
+				ExDividendDate: "2021-03-01T00:00:00",
+			},
+			want: []string{"amount -1.08 is not positive"},
+		},
+		{
+			name: "invalid dates",
+			tiprankDividend: entities.TipRankDividend{
+				Ticker:         "RY",
+				Amount:         1.08,
+				ExDividendDate: "2021-03-01",
+				RecordDate:     "March 2nd",
+				DividendDate:   "2021-13-24T00:00:00",
+			},
+			want: []string{`invalid exDividendDate "2021-03-01"`, `invalid recordDate "March 2nd"`, `invalid payoutDate "2021-13-24T00:00:00"`},
+		},
+		{
+			name: "dates out of order",
+			tiprankDividend: entities.TipRankDividend{
+				Ticker:         "RY",
+				Amount:         1.08,
+				ExDividendDate: "2021-03-02T00:00:00",
+				RecordDate:     "2021-03-01T00:00:00",
+				DividendDate:   "2021-02-28T00:00:00",
+			},
+			want: []string{"recordDate is before exDividendDate", "payoutDate is before exDividendDate", "payoutDate is before recordDate"},
+		},
+		{
+			name: "payout on ex-dividend date",
+			tiprankDividend: entities.TipRankDividend{
+				Ticker:         "RY",
+				Amount:         1.08,
+				ExDividendDate: "2021-03-01T00:00:00",
+				DividendDate:   "2021-03-01T00:00:00",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateTipRankDividend(&tt.tiprankDividend); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateTipRankDividend() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package calendar
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// holidayData holds exchange holidays of each calendar, one json file per calendar
+//
+//go:embed data/*.json
+var holidayData embed.FS
+
+// Calendar is the business calendar of an exchange, weekends and exchange holidays are non-trading days.
+// Holidays are only known between its first and last year, other years only skip weekends
+type Calendar struct {
+	Name      string
+	FirstYear int
+	LastYear  int
+	holidays  map[string]string // holiday name of each date
+}
+
+// calendarFile is the json layout of holiday data
+type calendarFile struct {
+	Name      string `json:"name"`
+	FirstYear int    `json:"firstYear"`
+	LastYear  int    `json:"lastYear"`
+	Holidays  []struct {
+		Date string `json:"date"`
+		Name string `json:"name"`
+	} `json:"holidays"`
+}
+
+var (
+	calendars     map[string]*Calendar
+	calendarsErr  error
+	calendarsOnce sync.Once
+)
+
+// GetCalendar gets business calendar of a given exchange, e.g. NYSE, TSX or LSE
+func GetCalendar(name string) (*Calendar, error) {
+	calendarsOnce.Do(func() {
+		calendars, calendarsErr = loadCalendars()
+	})
+
+	if calendarsErr != nil {
+		return nil, calendarsErr
+	}
+
+	calendar, ok := calendars[strings.ToUpper(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown calendar %s", name)
+	}
+
+	return calendar, nil
+}
+
+// IsBusinessDay checks whether the exchange trades on a given date
+func (c *Calendar) IsBusinessDay(date time.Time) bool {
+	if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		return false
+	}
+
+	_, holiday := c.GetHoliday(date)
+	return !holiday
+}
+
+// GetHoliday gets holiday name of a given date, false if the date is not a holiday
+func (c *Calendar) GetHoliday(date time.Time) (string, bool) {
+	name, ok := c.holidays[date.Format("2006-01-02")]
+	return name, ok
+}
+
+// Covers checks whether holidays of the year of a given date are known
+func (c *Calendar) Covers(date time.Time) bool {
+	return date.Year() >= c.FirstYear && date.Year() <= c.LastYear
+}
+
+// ValidateBusinessDay checks that a given date is a business day, e.g. an ex-dividend date
+func (c *Calendar) ValidateBusinessDay(date time.Time) error {
+	if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		return fmt.Errorf("%s is a %s", date.Format("2006-01-02"), date.Weekday())
+	}
+
+	if name, ok := c.GetHoliday(date); ok {
+		return fmt.Errorf("%s is %s on %s", date.Format("2006-01-02"), name, c.Name)
+	}
+
+	return nil
+}
+
+// loadCalendars loads every embedded calendar
+func loadCalendars() (map[string]*Calendar, error) {
+	entries, err := holidayData.ReadDir("data")
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := map[string]*Calendar{}
+	for _, entry := range entries {
+		data, err := holidayData.ReadFile(path.Join("data", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var file calendarFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("invalid calendar %s: %v", entry.Name(), err)
+		}
+
+		calendar := &Calendar{
+			Name:      file.Name,
+			FirstYear: file.FirstYear,
+			LastYear:  file.LastYear,
+			holidays:  map[string]string{},
+		}
+
+		for _, holiday := range file.Holidays {
+			if _, err := time.Parse("2006-01-02", holiday.Date); err != nil {
+				return nil, fmt.Errorf("invalid holiday of calendar %s: %v", file.Name, err)
+			}
+
+			calendar.holidays[holiday.Date] = holiday.Name
+		}
+
+		loaded[strings.ToUpper(file.Name)] = calendar
+	}
+
+	return loaded, nil
+}
@@ -0,0 +1,76 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalendarIsBusinessDay(t *testing.T) {
+	tests := []struct {
+		calendar string
+		date     string
+		want     bool
+		holiday  string
+	}{
+		{calendar: "NYSE", date: "2021-07-02", want: true},
+		{calendar: "NYSE", date: "2021-07-03", want: false},
+		{calendar: "NYSE", date: "2021-07-04", want: false},
+		{calendar: "NYSE", date: "2021-07-05", want: false, holiday: "Independence Day"},
+		{calendar: "TSX", date: "2021-07-01", want: false, holiday: "Canada Day"},
+		{calendar: "TSX", date: "2021-07-05", want: true},
+		{calendar: "LSE", date: "2021-05-03", want: false, holiday: "Early May Bank Holiday"},
+		{calendar: "lse", date: "2021-05-04", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.calendar+" "+tt.date, func(t *testing.T) {
+			c, err := GetCalendar(tt.calendar)
+			if err != nil {
+				t.Fatalf("GetCalendar(%s) error = %v", tt.calendar, err)
+			}
+
+			date, _ := time.Parse("2006-01-02", tt.date)
+
+			if got := c.IsBusinessDay(date); got != tt.want {
+				t.Errorf("IsBusinessDay() = %v, want %v", got, tt.want)
+			}
+
+			if got := c.ValidateBusinessDay(date); (got == nil) != tt.want {
+				t.Errorf("ValidateBusinessDay() = %v, want business day %v", got, tt.want)
+			}
+
+			if got, _ := c.GetHoliday(date); got != tt.holiday {
+				t.Errorf("GetHoliday() = %q, want %q", got, tt.holiday)
+			}
+		})
+	}
+}
+
+func TestCalendarCovers(t *testing.T) {
+	c, err := GetCalendar("NYSE")
+	if err != nil {
+		t.Fatalf("GetCalendar() error = %v", err)
+	}
+
+	tests := []struct {
+		year int
+		want bool
+	}{
+		{year: c.FirstYear - 1, want: false},
+		{year: c.FirstYear, want: true},
+		{year: c.LastYear, want: true},
+		{year: c.LastYear + 1, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := c.Covers(time.Date(tt.year, 6, 1, 0, 0, 0, 0, time.UTC)); got != tt.want {
+			t.Errorf("Covers(%d) = %v, want %v", tt.year, got, tt.want)
+		}
+	}
+}
+
+func TestGetCalendarUnknown(t *testing.T) {
+	if _, err := GetCalendar("NASDAQ"); err == nil {
+		t.Errorf("GetCalendar(NASDAQ) error = nil, want an error")
+	}
+}
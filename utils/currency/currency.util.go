@@ -1,18 +1,15 @@
 package currency
 
 import (
-	"fmt"
-
-	"github.com/lenoobz/aws-tiprank-dividend-scraper/consts"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/config"
 )
 
-// GetCountryCurrency gets currency code of a given country name
+// GetCountryCurrency gets currency code of a given TipRank country code
 func GetCountryCurrency(countryName string) (string, error) {
-	currency, found := consts.Currencies[countryName]
-
-	if !found {
-		return "", fmt.Errorf("not found %s's currency", countryName)
+	market, err := config.GetMarket(countryName)
+	if err != nil {
+		return "", err
 	}
 
-	return currency.Code, nil
+	return market.Currency, nil
 }
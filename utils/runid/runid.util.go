@@ -0,0 +1,34 @@
+package runid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	corid "github.com/lenoobz/aws-lambda-corid"
+)
+
+// runIDKey is the context key of the run id
+type runIDKey struct{}
+
+// New generates a new run id
+func New() string {
+	return uuid.New().String()
+}
+
+// NewContext returns a copy of a given context carrying the run id, a run id which is a uuid
+// is also set as correlation id so that every log of the run carries it
+func NewContext(ctx context.Context, runID string) context.Context {
+	ctx = context.WithValue(ctx, runIDKey{}, runID)
+
+	if id, err := uuid.Parse(runID); err == nil {
+		ctx = corid.NewContext(ctx, id)
+	}
+
+	return ctx
+}
+
+// FromContext gets run id of a given context, empty if none
+func FromContext(ctx context.Context) string {
+	runID, _ := ctx.Value(runIDKey{}).(string)
+	return runID
+}
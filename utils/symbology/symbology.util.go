@@ -0,0 +1,82 @@
+package symbology
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/config"
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+)
+
+// exchange describes how other vendors identify a canonical exchange
+type exchange struct {
+	yahooSuffix   string
+	bloombergCode string
+}
+
+// exchanges lists canonical exchanges, US is the US composite since TipRank does not tell NYSE from NASDAQ
+var exchanges = map[string]exchange{
+	"US":   {yahooSuffix: "", bloombergCode: "US"},
+	"TSX":  {yahooSuffix: ".TO", bloombergCode: "CN"},
+	"TSXV": {yahooSuffix: ".V", bloombergCode: "CN"},
+	"LSE":  {yahooSuffix: ".L", bloombergCode: "LN"},
+}
+
+// IsKnownExchange checks whether a given canonical exchange is known
+func IsKnownExchange(exchangeCode string) bool {
+	_, ok := exchanges[exchangeCode]
+	return ok
+}
+
+// Normalize gets canonical symbol of a TipRank ticker of a given TipRank country code,
+// e.g. TSE:RY of Canada gives TSX:RY and BRK.B of US gives US:BRK.B
+func Normalize(ticker string, countryCode string) (*entities.Symbol, error) {
+	market, err := config.GetMarket(countryCode)
+	if err != nil {
+		return nil, err
+	}
+
+	symbol := strings.ToUpper(strings.TrimSpace(ticker))
+	exchangeCode := market.DefaultExchange
+
+	for prefix, prefixExchange := range market.TickerPrefixes {
+		if strings.HasPrefix(symbol, prefix) {
+			symbol = strings.TrimPrefix(symbol, prefix)
+			exchangeCode = prefixExchange
+			break
+		}
+	}
+
+	if symbol == "" {
+		return nil, fmt.Errorf("invalid ticker %q", ticker)
+	}
+
+	if !IsKnownExchange(exchangeCode) {
+		return nil, fmt.Errorf("unknown exchange %s", exchangeCode)
+	}
+
+	return &entities.Symbol{
+		Exchange: exchangeCode,
+		Symbol:   symbol,
+	}, nil
+}
+
+// ToYahoo converts canonical symbol to Yahoo ticker, e.g. TSX:RCI.B gives RCI-B.TO
+func ToYahoo(symbol *entities.Symbol) (string, error) {
+	e, ok := exchanges[symbol.Exchange]
+	if !ok {
+		return "", fmt.Errorf("unknown exchange %s", symbol.Exchange)
+	}
+
+	return strings.ReplaceAll(symbol.Symbol, ".", "-") + e.yahooSuffix, nil
+}
+
+// ToBloomberg converts canonical symbol to Bloomberg ticker, e.g. TSX:RCI.B gives RCI/B CN
+func ToBloomberg(symbol *entities.Symbol) (string, error) {
+	e, ok := exchanges[symbol.Exchange]
+	if !ok {
+		return "", fmt.Errorf("unknown exchange %s", symbol.Exchange)
+	}
+
+	return strings.ReplaceAll(symbol.Symbol, ".", "/") + " " + e.bloombergCode, nil
+}
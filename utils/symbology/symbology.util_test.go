@@ -0,0 +1,76 @@
+package symbology
+
+import (
+	"testing"
+
+	"github.com/lenoobz/aws-tiprank-dividend-scraper/entities"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		ticker      string
+		countryCode string
+		want        string
+		wantErr     bool
+	}{
+		{ticker: "TSE:RY", countryCode: "Canada", want: "TSX:RY"},
+		{ticker: "RY", countryCode: "Canada", want: "TSX:RY"},
+		{ticker: "CVE:ABC", countryCode: "Canada", want: "TSXV:ABC"},
+		{ticker: " brk.b ", countryCode: "US", want: "US:BRK.B"},
+		{ticker: "LON:VOD", countryCode: "UK", want: "LSE:VOD"},
+		{ticker: "GB:BP", countryCode: "UK", want: "LSE:BP"},
+		{ticker: "TSE:", countryCode: "Canada", wantErr: true},
+		{ticker: "", countryCode: "US", wantErr: true},
+		{ticker: "RY", countryCode: "Mars", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.countryCode+" "+tt.ticker, func(t *testing.T) {
+			symbol, err := Normalize(tt.ticker, tt.countryCode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Normalize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && symbol.String() != tt.want {
+				t.Errorf("Normalize() = %s, want %s", symbol, tt.want)
+			}
+		})
+	}
+}
+
+func TestToVendorTickers(t *testing.T) {
+	tests := []struct {
+		symbol        entities.Symbol
+		wantYahoo     string
+		wantBloomberg string
+		wantErr       bool
+	}{
+		{symbol: entities.Symbol{Exchange: "US", Symbol: "BRK.B"}, wantYahoo: "BRK-B", wantBloomberg: "BRK/B US"},
+		{symbol: entities.Symbol{Exchange: "TSX", Symbol: "RCI.B"}, wantYahoo: "RCI-B.TO", wantBloomberg: "RCI/B CN"},
+		{symbol: entities.Symbol{Exchange: "TSXV", Symbol: "ABC"}, wantYahoo: "ABC.V", wantBloomberg: "ABC CN"},
+		{symbol: entities.Symbol{Exchange: "LSE", Symbol: "VOD"}, wantYahoo: "VOD.L", wantBloomberg: "VOD LN"},
+		{symbol: entities.Symbol{Exchange: "XETRA", Symbol: "SAP"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.symbol.String(), func(t *testing.T) {
+			yahoo, err := ToYahoo(&tt.symbol)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ToYahoo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			bloomberg, err := ToBloomberg(&tt.symbol)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ToBloomberg() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if yahoo != tt.wantYahoo {
+				t.Errorf("ToYahoo() = %s, want %s", yahoo, tt.wantYahoo)
+			}
+
+			if bloomberg != tt.wantBloomberg {
+				t.Errorf("ToBloomberg() = %s, want %s", bloomberg, tt.wantBloomberg)
+			}
+		})
+	}
+}